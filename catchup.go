@@ -0,0 +1,186 @@
+// Package schedule
+// file contains the catch-up of the task runs missed during downtime, with the stores of last run times.
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CatchUpMode the way to run the missed occurrences of a task
+type CatchUpMode int
+
+const (
+	// CatchUpOnce run the task once for all the missed occurrences, nothing is caught up if the task is due now
+	CatchUpOnce CatchUpMode = iota
+	// CatchUpEach run the task for each missed occurrence
+	CatchUpEach
+)
+
+// String return the name of catch-up mode
+func (m CatchUpMode) String() string {
+	if m == CatchUpEach {
+		return "each"
+	}
+	return "once"
+}
+
+// LastRunStore the store of the last time a task is scheduled to run, it's used by the tasks with `CatchUp`
+type LastRunStore interface {
+	// LastRun return the last run time of the task, the zero time should be returned if it has never run
+	LastRun(ctx context.Context, name string) (time.Time, error)
+	// SetLastRun set the last run time of the task
+	SetLastRun(ctx context.Context, name string, t time.Time) error
+}
+
+// MemoryLastRunStore the in-process last run store, it only catches up the runs missed in daemon mode
+type MemoryLastRunStore struct {
+	mu   sync.Mutex
+	runs map[string]time.Time
+}
+
+// NewMemoryLastRunStore create instance of in-process last run store
+func NewMemoryLastRunStore() *MemoryLastRunStore {
+	return &MemoryLastRunStore{runs: make(map[string]time.Time)}
+}
+
+// LastRun return the last run time of the task
+func (m *MemoryLastRunStore) LastRun(ctx context.Context, name string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runs[name], nil
+}
+
+// SetLastRun set the last run time of the task
+func (m *MemoryLastRunStore) SetLastRun(ctx context.Context, name string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[name] = t
+	return nil
+}
+
+// FileLastRunStore the last run store base on a JSON file,
+// so the processes launched by crontab on the same host can catch up the missed runs.
+type FileLastRunStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileLastRunStore create instance of last run store with the path of JSON file, the file is created on first write.
+func NewFileLastRunStore(path string) *FileLastRunStore {
+	return &FileLastRunStore{path: path}
+}
+
+// LastRun return the last run time of the task
+func (f *FileLastRunStore) LastRun(ctx context.Context, name string) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runs, err := f.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return runs[name], nil
+}
+
+// SetLastRun set the last run time of the task, the file is replaced atomically
+func (f *FileLastRunStore) SetLastRun(ctx context.Context, name string, t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	runs, err := f.read()
+	if err != nil {
+		return err
+	}
+	runs[name] = t
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *FileLastRunStore) read() (map[string]time.Time, error) {
+	runs := make(map[string]time.Time)
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return runs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// catchUp run the occurrences of the task missed in its catch-up window before now,
+// which are found from the last run time in the last run store. due is true if the task will run now.
+// The time is saved as the baseline if the task has no last run time, so a new task doesn't catch up at all.
+func (s *Scheduler) catchUp(e *Event, now time.Time, due bool) {
+	name, window, mode := e.catchUpPolicy()
+	if window <= 0 || (due && mode == CatchUpOnce) {
+		return
+	}
+	last, err := s.lastRuns.LastRun(s.ctx, name)
+	if err != nil {
+		s.logAt(LevelError, "Failed to read the last run of task", Field{FieldTask, name}, Field{FieldError, err})
+		return
+	}
+	if last.IsZero() {
+		// nothing is missed before the task is first seen
+		s.setLastRun(name, now)
+		return
+	}
+	from := now.Add(-window)
+	if last.After(from) {
+		from = last
+	}
+	sc := e.Schedule()
+	var missed []time.Time
+	current := now.Truncate(time.Minute)
+	for at := from.Truncate(time.Minute).Add(time.Minute); at.Before(current); at = at.Add(time.Minute) {
+		if sc.isTimeMatched(at) && sc.limit.allow(at.In(sc.location)) {
+			missed = append(missed, at)
+		}
+	}
+	if len(missed) == 0 {
+		return
+	}
+	if mode == CatchUpOnce {
+		missed = missed[len(missed)-1:]
+	}
+	for _, at := range missed {
+		if sc.limit.When != nil && !sc.limit.When(s.ctx) {
+			s.skip(name, SkipConstraint)
+			continue
+		}
+		s.logAt(LevelInfo, "Catch up the missed run of task", Field{FieldTask, name}, Field{"missed", at})
+		s.dispatch(e, at)
+	}
+	s.setLastRun(name, missed[len(missed)-1])
+}
+
+// setLastRun save the time the task is scheduled to run
+func (s *Scheduler) setLastRun(name string, at time.Time) {
+	if err := s.lastRuns.SetLastRun(s.ctx, name, at); err != nil {
+		s.logAt(LevelError, "Failed to save the last run of task", Field{FieldTask, name}, Field{FieldError, err})
+	}
+}
@@ -0,0 +1,232 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingLastRunStore the last run store always fails
+type failingLastRunStore struct{}
+
+func (failingLastRunStore) LastRun(ctx context.Context, name string) (time.Time, error) {
+	return time.Time{}, errors.New("disk failure")
+}
+
+func (failingLastRunStore) SetLastRun(ctx context.Context, name string, t time.Time) error {
+	return errors.New("disk failure")
+}
+
+func catchUpScheduler(now string, store LastRunStore) *Scheduler {
+	at, _ := time.Parse("2006-01-02 15:04:05", now)
+	return New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(at)), WithLastRunStore(store))
+}
+
+func parseTime(v string) time.Time {
+	t, _ := time.Parse("2006-01-02 15:04:05", v)
+	return t
+}
+
+func TestCatchUpMode_String(t *testing.T) {
+	assert.Equal(t, "once", CatchUpOnce.String())
+	assert.Equal(t, "each", CatchUpEach.String())
+}
+
+func TestFileLastRunStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "last-runs.json")
+	store := NewFileLastRunStore(path)
+	last, err := store.LastRun(ctx, "report")
+	assert.NoError(t, err)
+	assert.True(t, last.IsZero())
+
+	at := parseTime("2022-10-05 02:00:00")
+	assert.NoError(t, store.SetLastRun(ctx, "report", at))
+	assert.NoError(t, store.SetLastRun(ctx, "cleanup", at.Add(time.Hour)))
+	last, err = NewFileLastRunStore(path).LastRun(ctx, "report")
+	assert.NoError(t, err)
+	assert.True(t, at.Equal(last))
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err = store.LastRun(ctx, "report")
+	assert.Error(t, err)
+	assert.Error(t, store.SetLastRun(ctx, "report", at))
+
+	store = NewFileLastRunStore(filepath.Join(t.TempDir(), "missing", "last-runs.json"))
+	assert.Error(t, store.SetLastRun(ctx, "report", at))
+}
+
+func TestEvent_CatchUp(t *testing.T) {
+	store := NewMemoryLastRunStore()
+	ctx := context.Background()
+	assert.NoError(t, store.SetLastRun(ctx, "report", parseTime("2022-10-04 02:00:00")))
+	s := catchUpScheduler("2022-10-05 02:01:00", store)
+	count := 0
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("report").DailyAt("02:00").CatchUp(time.Hour, CatchUpOnce)
+	results, err := s.Wait()
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, 1, count)
+	last, _ := store.LastRun(ctx, "report")
+	assert.Equal(t, parseTime("2022-10-05 02:00:00"), last)
+	assert.Equal(t, []string{"CatchUp(1h0m0s, once)"}, s.Tasks()[0].Constraints)
+
+	// the missed run has been caught up
+	s = catchUpScheduler("2022-10-05 02:02:00", store)
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("report").DailyAt("02:00").CatchUp(time.Hour, CatchUpOnce)
+	s.Start()
+	assert.Equal(t, 1, count)
+
+	// the missed run is out of the window
+	s = catchUpScheduler("2022-10-06 03:01:00", store)
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("report").DailyAt("02:00").CatchUp(time.Hour, CatchUpOnce)
+	s.Start()
+	assert.Equal(t, 1, count)
+}
+
+func TestEvent_CatchUp_each(t *testing.T) {
+	store := NewMemoryLastRunStore()
+	ctx := context.Background()
+	assert.NoError(t, store.SetLastRun(ctx, "sync", parseTime("2022-10-05 01:00:00")))
+	s := catchUpScheduler("2022-10-05 02:00:00", store)
+	var mu sync.Mutex
+	var runs []time.Time
+	s.Task(func(ctx context.Context) {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, time.Now())
+	}).Name("sync").EveryFifteenMinutes().CatchUp(2*time.Hour, CatchUpEach)
+	results, err := s.Wait()
+	assert.NoError(t, err)
+	// 01:15, 01:30, 01:45 and the run due now
+	assert.Len(t, results, 4)
+	assert.Len(t, runs, 4)
+	last, _ := store.LastRun(ctx, "sync")
+	assert.Equal(t, parseTime("2022-10-05 02:00:00"), last)
+}
+
+func TestEvent_CatchUp_due(t *testing.T) {
+	store := NewMemoryLastRunStore()
+	ctx := context.Background()
+	assert.NoError(t, store.SetLastRun(ctx, "sync", parseTime("2022-10-05 01:00:00")))
+	s := catchUpScheduler("2022-10-05 02:00:00", store)
+	count := 0
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("sync").EveryFifteenMinutes().CatchUp(2*time.Hour, CatchUpOnce)
+	s.Start()
+	assert.Equal(t, 1, count)
+
+	// the task without last run time is not caught up
+	store = NewMemoryLastRunStore()
+	s = catchUpScheduler("2022-10-05 02:20:00", store)
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("sync").EveryFifteenMinutes().CatchUp(time.Hour, CatchUpEach)
+	s.Start()
+	assert.Equal(t, 1, count)
+	last, _ := store.LastRun(ctx, "sync")
+	assert.Equal(t, parseTime("2022-10-05 02:20:00"), last)
+
+	// the occurrences not allowed by constraints are not caught up
+	s = catchUpScheduler("2022-10-05 03:20:00", store)
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("sync").EveryFifteenMinutes().Between("02:40", "03:00").CatchUp(time.Hour, CatchUpEach)
+	s.Start()
+	// 02:45 and 03:00
+	assert.Equal(t, 3, count)
+}
+
+func TestEvent_CatchUp_when(t *testing.T) {
+	logger := &recordLogger{}
+	store := NewMemoryLastRunStore()
+	assert.NoError(t, store.SetLastRun(context.Background(), "report", parseTime("2022-10-04 02:00:00")))
+	s := catchUpScheduler("2022-10-05 02:01:00", store)
+	s.SetStructuredLogger(logger)
+	count := 0
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("report").DailyAt("02:00").When(func(ctx context.Context) bool {
+		return false
+	}).CatchUp(time.Hour, CatchUpOnce)
+	s.Start()
+	assert.Equal(t, 0, count)
+	skipped := logger.find("Skip task")
+	assert.NotNil(t, skipped)
+	assert.Equal(t, SkipConstraint, skipped.fields["reason"])
+}
+
+func TestEvent_CatchUp_error(t *testing.T) {
+	logger := &recordLogger{}
+	s := catchUpScheduler("2022-10-05 02:00:00", failingLastRunStore{})
+	s.SetStructuredLogger(logger).SetLastRunStore(nil)
+	count := 0
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("report").DailyAt("02:00").CatchUp(time.Hour, CatchUpEach)
+	s.Start()
+	assert.Equal(t, 1, count)
+	assert.NotNil(t, logger.find("Failed to read the last run of task"))
+	assert.NotNil(t, logger.find("Failed to save the last run of task"))
+}
+
+func TestScheduler_tick_catchUp(t *testing.T) {
+	store := NewMemoryLastRunStore()
+	s := catchUpScheduler("2022-10-05 02:00:00", store)
+	var missed []string
+	logger := &recordLogger{}
+	s.SetStructuredLogger(logger)
+	s.Task(func(ctx context.Context) {}).Name("sync").EveryFiveMinutes().CatchUp(time.Hour, CatchUpEach)
+	s.Start()
+	// the daemon is suspended from 02:01 to 02:16
+	s.tick(parseTime("2022-10-05 02:16:00"))
+	s.wait()
+	logger.mu.Lock()
+	for _, l := range logger.logs {
+		if l.msg == "Catch up the missed run of task" {
+			missed = append(missed, l.fields["missed"].(time.Time).Format("15:04"))
+		}
+	}
+	logger.mu.Unlock()
+	sort.Strings(missed)
+	assert.Equal(t, []string{"02:05", "02:10", "02:15"}, missed)
+}
+
+func TestEvent_CatchUp_skipped(t *testing.T) {
+	store := NewMemoryLastRunStore()
+	ctx := context.Background()
+	assert.NoError(t, store.SetLastRun(ctx, "report", parseTime("2022-10-04 02:00:00")))
+	clock := NewFakeClock(parseTime("2022-10-05 02:00:00"))
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(clock), WithLastRunStore(store))
+	count := 0
+	allowed := false
+	s.Task(func(ctx context.Context) {
+		count++
+	}).Name("report").DailyAt("02:00").When(func(ctx context.Context) bool {
+		return allowed
+	}).CatchUp(time.Hour, CatchUpOnce)
+	s.Start()
+	assert.Equal(t, 0, count)
+	last, _ := store.LastRun(ctx, "report")
+	assert.Equal(t, parseTime("2022-10-05 02:00:00"), last)
+
+	// the occurrence skipped by `When` is not caught up
+	allowed = true
+	s.tick(parseTime("2022-10-05 02:01:00"))
+	s.wait()
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,214 @@
+// Package cli
+// file contains the command line helper to run a configured scheduler with the subcommands:
+//
+//	run          run the due tasks once, for crontab
+//	work         run the scheduler as a daemon until SIGINT or SIGTERM
+//	list         list the registered tasks
+//	test <name>  run a task now regardless of its frequency and constraints
+//
+// The tasks should be registered by `Task` or `Job`, so they are not evaluated before the subcommand is chosen.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/iflamed/schedule"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// The exit codes of the subcommands
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+// App the command line application of a scheduler
+type App struct {
+	Name      string
+	Scheduler *schedule.Scheduler
+	Stdout    io.Writer
+	Stderr    io.Writer
+}
+
+// New create the command line application of the scheduler, which is named by the program name.
+func New(s *schedule.Scheduler) *App {
+	name := "schedule"
+	if len(os.Args) > 0 {
+		name = os.Args[0]
+	}
+	return &App{
+		Name:      name,
+		Scheduler: s,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+}
+
+// Main run the subcommand of the program arguments and exit with its exit code, it's the whole `main` of a scheduler:
+//
+//	func main() {
+//		s := schedule.NewScheduler(context.Background(), time.Local)
+//		s.Task(report).Name("report").DailyAt("09:00")
+//		cli.Main(s)
+//	}
+func Main(s *schedule.Scheduler) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := New(s).Run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// Run run the subcommand of args and return the exit code, the `work` subcommand stops when ctx is done.
+func (a *App) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		a.usage()
+		return ExitUsage
+	}
+	switch args[0] {
+	case "run":
+		return a.run(args[1:])
+	case "work":
+		return a.work(ctx, args[1:])
+	case "list":
+		return a.list(args[1:])
+	case "test":
+		return a.test(args[1:])
+	case "help", "-h", "-help", "--help":
+		a.usage()
+		return ExitOK
+	default:
+		_, _ = fmt.Fprintf(a.Stderr, "unknown command %q\n", args[0])
+		a.usage()
+		return ExitUsage
+	}
+}
+
+func (a *App) usage() {
+	_, _ = fmt.Fprintf(a.Stderr, `Usage: %s <command> [flags]
+
+Commands:
+  run          run the due tasks once, for crontab
+  work         run the scheduler as a daemon until interrupted
+  list         list the registered tasks
+  test <name>  run a task now regardless of its frequency and constraints
+
+Run '%s <command> -h' for the flags of a command.
+`, a.Name, a.Name)
+}
+
+// flags create the flag set of a subcommand, the errors are written to stderr
+func (a *App) flags(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(a.Stderr, "Usage: %s %s [flags]%s\n", a.Name, name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parse the flags and return the exit code if the command should not go on
+func parse(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	return ExitOK, true
+}
+
+// graceFlag add the flag of the grace period to wait running tasks
+func graceFlag(fs *flag.FlagSet) *time.Duration {
+	return fs.Duration("grace", 0, "the max duration to wait running tasks, 0 means wait until finished")
+}
+
+func (a *App) run(args []string) int {
+	fs := a.flags("run", "")
+	grace := graceFlag(fs)
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if *grace > 0 {
+		a.Scheduler.SetGracePeriod(*grace)
+	}
+	results, err := a.Scheduler.Wait()
+	for _, r := range results {
+		_, _ = fmt.Fprintln(a.Stdout, r.String())
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(a.Stderr, err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+func (a *App) work(ctx context.Context, args []string) int {
+	fs := a.flags("work", "")
+	grace := graceFlag(fs)
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if *grace > 0 {
+		a.Scheduler.SetGracePeriod(*grace)
+	}
+	if running := a.Scheduler.Run(ctx); len(running) > 0 {
+		_, _ = fmt.Fprintf(a.Stderr, "tasks still running after the grace period: %v\n", running)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+func (a *App) list(args []string) int {
+	fs := a.flags("list", "")
+	asJSON := fs.Bool("json", false, "print the tasks as JSON")
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	var err error
+	if *asJSON {
+		err = a.Scheduler.ListJSON(a.Stdout)
+	} else {
+		err = a.Scheduler.List(a.Stdout)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(a.Stderr, err)
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+func (a *App) test(args []string) int {
+	fs := a.flags("test", " <name>")
+	grace := graceFlag(fs)
+	if code, ok := parse(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if *grace > 0 {
+		a.Scheduler.SetGracePeriod(*grace)
+	}
+	r, err := a.Scheduler.RunTask(fs.Arg(0))
+	if errors.Is(err, schedule.ErrTaskNotFound) {
+		_, _ = fmt.Fprintf(a.Stderr, "task %q not found, run '%s list' for the registered tasks\n", fs.Arg(0), a.Name)
+		return ExitUsage
+	}
+	if !errors.Is(err, schedule.ErrTaskSkipped) {
+		_, _ = fmt.Fprintln(a.Stdout, r.String())
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(a.Stderr, err)
+		return ExitFailure
+	}
+	return ExitOK
+}
@@ -0,0 +1,140 @@
+// Package cli
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/iflamed/schedule"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newApp(s *schedule.Scheduler) (*App, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	app := New(s)
+	app.Name = "scheduler"
+	app.Stdout = &stdout
+	app.Stderr = &stderr
+	return app, &stdout, &stderr
+}
+
+func newScheduler() *schedule.Scheduler {
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	return schedule.New(context.Background(), schedule.WithLocation(time.UTC), schedule.WithClock(schedule.NewFakeClock(now)))
+}
+
+func TestApp_usage(t *testing.T) {
+	app, _, stderr := newApp(newScheduler())
+	assert.Equal(t, ExitUsage, app.Run(context.Background(), nil))
+	assert.Contains(t, stderr.String(), "Usage: scheduler <command> [flags]")
+	stderr.Reset()
+	assert.Equal(t, ExitOK, app.Run(context.Background(), []string{"help"}))
+	assert.Contains(t, stderr.String(), "test <name>")
+	stderr.Reset()
+	assert.Equal(t, ExitUsage, app.Run(context.Background(), []string{"deploy"}))
+	assert.Contains(t, stderr.String(), `unknown command "deploy"`)
+	stderr.Reset()
+	assert.Equal(t, ExitOK, app.Run(context.Background(), []string{"list", "-h"}))
+	assert.Contains(t, stderr.String(), "Usage: scheduler list [flags]")
+	assert.Equal(t, ExitUsage, app.Run(context.Background(), []string{"run", "-grace", "soon"}))
+}
+
+func TestApp_run(t *testing.T) {
+	s := newScheduler()
+	var count int32
+	s.Task(func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+	}).Name("due").EveryFiveMinutes()
+	s.Task(func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+	}).Name("idle").Hourly()
+	app, stdout, _ := newApp(s)
+	assert.Equal(t, ExitOK, app.Run(context.Background(), []string{"run", "-grace", "1s"}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	assert.Equal(t, "due: success\n", stdout.String())
+
+	s = newScheduler()
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("broken").EveryMinute()
+	app, _, stderr := newApp(s)
+	assert.Equal(t, ExitFailure, app.Run(context.Background(), []string{"run"}))
+	assert.Contains(t, stderr.String(), "broken")
+}
+
+func TestApp_work(t *testing.T) {
+	s := newScheduler()
+	var count int32
+	s.Task(func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+	}).EveryMinute()
+	app, _, _ := newApp(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	assert.Equal(t, ExitOK, app.Run(ctx, []string{"work"}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	s = newScheduler()
+	s.Task(func(ctx context.Context) {
+		time.Sleep(200 * time.Millisecond)
+	}).Name("slow").EveryMinute()
+	app, _, stderr := newApp(s)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	assert.Equal(t, ExitFailure, app.Run(ctx, []string{"work", "-grace", "10ms"}))
+	assert.Contains(t, stderr.String(), "[slow]")
+}
+
+func TestApp_list(t *testing.T) {
+	s := newScheduler()
+	s.Task(func(ctx context.Context) {}).Name("report").DailyAt("09:30")
+	app, stdout, _ := newApp(s)
+	assert.Equal(t, ExitOK, app.Run(context.Background(), []string{"list"}))
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "30 9 * * *")
+
+	stdout.Reset()
+	assert.Equal(t, ExitOK, app.Run(context.Background(), []string{"list", "-json"}))
+	var entries []schedule.ListEntry
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "report", entries[0].Name)
+}
+
+func TestApp_test(t *testing.T) {
+	s := newScheduler()
+	var count int32
+	s.Task(func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+	}).Name("report").Yearly()
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("broken").Yearly()
+	app, stdout, stderr := newApp(s)
+	assert.Equal(t, ExitOK, app.Run(context.Background(), []string{"test", "report"}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	assert.Equal(t, "report: success\n", stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, ExitFailure, app.Run(context.Background(), []string{"test", "broken"}))
+	assert.Equal(t, "broken: failed: broken\n", stdout.String())
+
+	stderr.Reset()
+	assert.Equal(t, ExitUsage, app.Run(context.Background(), []string{"test", "missing"}))
+	assert.Contains(t, stderr.String(), `task "missing" not found`)
+	stderr.Reset()
+	assert.Equal(t, ExitUsage, app.Run(context.Background(), []string{"test"}))
+	assert.Contains(t, stderr.String(), "Usage: scheduler test [flags] <name>")
+}
@@ -0,0 +1,84 @@
+// Package schedule
+// file contains the clock of scheduler, and the simulation of schedules with a range of time.
+package schedule
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock the source of current time of scheduler
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock the clock of system time, it's used by default
+type SystemClock struct{}
+
+// Now return the current system time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock the clock can be set and advanced manually, it's used to test the schedules
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock create instance of fake clock with the current time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now return the current time of fake clock
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set set the current time of fake clock
+func (f *FakeClock) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance move the current time of fake clock forward by d
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// SimulatedRun the task would have fired at the time
+type SimulatedRun struct {
+	At   time.Time
+	Task string
+}
+
+// Simulate replay every minute from `from` to `to` (both inclusive), and return the registered tasks would have fired.
+// The tasks are not run, the `When` constraint is evaluated with the context of scheduler.
+func (s *Scheduler) Simulate(from, to time.Time) []SimulatedRun {
+	var runs []SimulatedRun
+	events := s.registered()
+	schedules := make([]*Schedule, len(events))
+	names := make([]string, len(events))
+	for i, e := range events {
+		schedules[i] = e.Schedule()
+		names[i] = e.info().Name
+	}
+	at := from.Truncate(time.Minute)
+	if at.Before(from) {
+		at = at.Add(time.Minute)
+	}
+	for ; !at.After(to); at = at.Add(time.Minute) {
+		for i, sc := range schedules {
+			if sc.isDue(s.ctx, at) {
+				runs = append(runs, SimulatedRun{At: at.In(sc.location), Task: names[i]})
+			}
+		}
+	}
+	return runs
+}
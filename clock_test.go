@@ -0,0 +1,51 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	now := time.Date(2022, 10, 5, 15, 30, 0, 0, time.UTC)
+	c := NewFakeClock(now)
+	assert.Equal(t, now, c.Now())
+	c.Advance(time.Minute)
+	assert.Equal(t, now.Add(time.Minute), c.Now())
+	c.Set(now)
+	assert.Equal(t, now, c.Now())
+	assert.WithinDuration(t, time.Now(), SystemClock{}.Now(), time.Second)
+}
+
+func TestScheduler_SetClock(t *testing.T) {
+	prc, _ := time.LoadLocation("Asia/Shanghai")
+	s := NewScheduler(context.Background(), prc)
+	s.SetClock(nil).SetClock(NewFakeClock(time.Date(2022, 10, 5, 1, 0, 0, 0, time.UTC)))
+	var mark bool
+	s.DailyAt("09:00").CallFunc(func(ctx context.Context) {
+		mark = true
+	})
+	s.Start()
+	assert.True(t, mark)
+}
+
+func TestScheduler_Simulate(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.Task(func(ctx context.Context) {
+		t.Fatal("the task should not run")
+	}).Name("quarter").EveryFifteenMinutes().Weekdays()
+	s.Task(func(ctx context.Context) {}).Name("nightly").DailyAt("00:30")
+	s.Task(func(ctx context.Context) {}).Name("never").EveryMinute().When(func(ctx context.Context) bool {
+		return false
+	})
+	from := time.Date(2022, 10, 7, 23, 44, 30, 0, time.UTC)
+	to := time.Date(2022, 10, 8, 0, 30, 0, 0, time.UTC)
+	runs := s.Simulate(from, to)
+	assert.Equal(t, []SimulatedRun{
+		{At: time.Date(2022, 10, 7, 23, 45, 0, 0, time.UTC), Task: "quarter"},
+		{At: time.Date(2022, 10, 8, 0, 30, 0, 0, time.UTC), Task: "nightly"},
+	}, runs)
+	assert.Empty(t, s.Simulate(to, from))
+}
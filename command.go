@@ -0,0 +1,186 @@
+// Package schedule
+// file contains the task to run an external command.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// maxCommandOutput the max bytes of stdout and stderr kept in memory per run, the earlier output is dropped
+const maxCommandOutput = 64 << 10
+
+// maxErrorStderr the max bytes of stderr included in the error of failed command
+const maxErrorStderr = 512
+
+// CommandTask the task to run an external command, the process group is killed when the task context is done.
+// The output and exit code of the last finished run are recorded, the output is also written to the output of task.
+type CommandTask struct {
+	Path string
+	Args []string
+	// Env the extra environment variables like `KEY=value`, the environment of current process is inherited
+	Env []string
+	// Dir the working directory, the directory of current process is used if it's empty
+	Dir  string
+	mu   sync.Mutex
+	last CommandOutput
+}
+
+// CommandOutput the output and exit code of a command run, only the last 64KiB of stdout and stderr are kept.
+// The exit code is -1 if the command is not run, failed to start or killed.
+type CommandOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// CommandError the error of command which exits with non-zero code or is killed,
+// the message contains the exit code and the tail of stderr.
+type CommandError struct {
+	Path   string
+	Output CommandOutput
+	Err    error
+}
+
+// Error describe the failed command with the tail of stderr
+func (e *CommandError) Error() string {
+	msg := "exec " + e.Path + ": " + e.Err.Error()
+	stderr := strings.TrimSpace(e.Output.Stderr)
+	if len(stderr) > maxErrorStderr {
+		stderr = "..." + stderr[len(stderr)-maxErrorStderr:]
+	}
+	if stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+// Unwrap return the error of process like *exec.ExitError, or the error of context if the command is killed
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// NewCommandTask create instance of command task with the command name or path and its arguments
+func NewCommandTask(path string, args ...string) *CommandTask {
+	return &CommandTask{
+		Path: path,
+		Args: args,
+		last: CommandOutput{ExitCode: -1},
+	}
+}
+
+// WithEnv add the environment variables like `KEY=value`
+func (c *CommandTask) WithEnv(env ...string) *CommandTask {
+	c.Env = append(c.Env, env...)
+	return c
+}
+
+// WithDir set the working directory
+func (c *CommandTask) WithDir(dir string) *CommandTask {
+	c.Dir = dir
+	return c
+}
+
+// String return the command line
+func (c *CommandTask) String() string {
+	return strings.Join(append([]string{c.Path}, c.Args...), " ")
+}
+
+// Run run the command and wait it to exit, the error is returned if it can't start or exits with non-zero code.
+// The error is a *CommandError if the command exits with non-zero code or is killed.
+func (c *CommandTask) Run(ctx context.Context) error {
+	out, err := c.RunOutput(ctx)
+	c.record(out)
+	return err
+}
+
+// RunOutput run the command like `Run` and return the output of this run,
+// use it instead of `Stdout`, `Stderr` and `ExitCode` if the command runs concurrently.
+func (c *CommandTask) RunOutput(ctx context.Context) (CommandOutput, error) {
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	stdout := &tailBuffer{max: maxCommandOutput}
+	stderr := &tailBuffer{max: maxCommandOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if out, ok := outputFrom(ctx); ok {
+		cmd.Stdout = io.MultiWriter(stdout, out)
+		cmd.Stderr = io.MultiWriter(stderr, out)
+	}
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return CommandOutput{ExitCode: -1}, fmt.Errorf("exec %s: %w", c.Path, err)
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	out := CommandOutput{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: cmd.ProcessState.ExitCode()}
+	if err != nil && ctx.Err() != nil {
+		return out, &CommandError{Path: c.Path, Output: out, Err: fmt.Errorf("%w: %v", ctx.Err(), err)}
+	}
+	if err != nil {
+		return out, &CommandError{Path: c.Path, Output: out, Err: err}
+	}
+	return out, nil
+}
+
+// Stdout return the standard output of the last finished run
+func (c *CommandTask) Stdout() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last.Stdout
+}
+
+// Stderr return the standard error of the last finished run
+func (c *CommandTask) Stderr() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last.Stderr
+}
+
+// ExitCode return the exit code of the last finished run, it's -1 if the command is not run, failed to start or killed
+func (c *CommandTask) ExitCode() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last.ExitCode
+}
+
+// record keep the output of a finished run as a whole, so the output and exit code are from the same run
+func (c *CommandTask) record(out CommandOutput) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = out
+}
+
+// tailBuffer the writer keeps the last max bytes written to it
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+// Package schedule
+// file contains the process control of command task on the platforms without process group.
+package schedule
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup do nothing, the process group is not supported
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kill the process of the command only
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
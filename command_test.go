@@ -0,0 +1,103 @@
+//go:build unix
+
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandTask_Run(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCommandTask("sh", "-c", `echo "$GREETING $(pwd)"; echo oops >&2`).WithEnv("GREETING=hello").WithDir(dir)
+	assert.Equal(t, -1, c.ExitCode())
+	assert.NoError(t, c.Run(context.Background()))
+	resolved, _ := filepath.EvalSymlinks(dir)
+	assert.Equal(t, "hello "+resolved+"\n", c.Stdout())
+	assert.Equal(t, "oops\n", c.Stderr())
+	assert.Equal(t, 0, c.ExitCode())
+	assert.Equal(t, `sh -c echo "$GREETING $(pwd)"; echo oops >&2`, c.String())
+}
+
+func TestCommandTask_Run_error(t *testing.T) {
+	c := NewCommandTask("sh", "-c", "echo failed >&2; exit 3")
+	err := c.Run(context.Background())
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+	assert.EqualError(t, err, "exec sh: exit status 3: failed")
+	var cmdErr *CommandError
+	assert.True(t, errors.As(err, &cmdErr))
+	assert.Equal(t, CommandOutput{Stderr: "failed\n", ExitCode: 3}, cmdErr.Output)
+	assert.Equal(t, 3, c.ExitCode())
+	assert.Equal(t, "failed\n", c.Stderr())
+
+	c = NewCommandTask(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, c.Run(context.Background()))
+	assert.Equal(t, -1, c.ExitCode())
+}
+
+func TestCommandTask_Run_cancel(t *testing.T) {
+	// the child process of shell is killed with the process group
+	c := NewCommandTask("sh", "-c", "sleep 10 & wait")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	err := c.Run(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(begin), 5*time.Second)
+	assert.Equal(t, -1, c.ExitCode())
+}
+
+func TestCommandTask_RunOutput(t *testing.T) {
+	// the output is kept per run, and only the tail of output is kept in memory
+	c := NewCommandTask("sh", "-c", `head -c 70000 /dev/zero | tr '\0' x; echo; echo "$0"`)
+	c.Args = append(c.Args, "first")
+	first, err := c.RunOutput(context.Background())
+	assert.NoError(t, err)
+	c.Args[len(c.Args)-1] = "second"
+	second, err := c.RunOutput(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, first.Stdout, maxCommandOutput)
+	assert.True(t, strings.HasSuffix(first.Stdout, "x\nfirst\n"))
+	assert.True(t, strings.HasSuffix(second.Stdout, "x\nsecond\n"))
+	assert.Equal(t, -1, c.ExitCode())
+
+	c = NewCommandTask("sh", "-c", `head -c 2000 /dev/zero | tr '\0' x >&2; echo >&2; echo fatal >&2; exit 2`)
+	_, err = c.RunOutput(context.Background())
+	assert.True(t, strings.HasPrefix(err.Error(), "exec sh: exit status 2: ...xxx"))
+	assert.True(t, strings.HasSuffix(err.Error(), "x\nfatal"))
+	assert.Less(t, len(err.Error()), maxErrorStderr+50)
+}
+
+func TestScheduler_Exec(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.Exec("sh", "-c", "exit 1").EveryMinute()
+	s.Exec("true").Name("ok").EveryMinute()
+	results, err := s.Wait()
+	assert.Error(t, err)
+	assert.Len(t, results, 2)
+	tasks := s.Tasks()
+	assert.Equal(t, "sh -c exit 1", tasks[0].Name)
+	assert.Equal(t, StatusFailed, tasks[0].Status)
+	assert.Equal(t, "ok", tasks[1].Name)
+	assert.Equal(t, StatusSuccess, tasks[1].Status)
+}
+
+func TestCommandTask_output(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "command.log")
+	s := NewScheduler(context.Background(), time.UTC)
+	s.Exec("sh", "-c", "echo out; echo err >&2").EveryMinute().AppendOutputTo(path)
+	s.Start()
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "out\n")
+	assert.Contains(t, string(content), "err\n")
+}
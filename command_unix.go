@@ -0,0 +1,20 @@
+//go:build unix
+
+// Package schedule
+// file contains the process group control of command task on unix.
+package schedule
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup run the command in a new process group, so its children can be killed together
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kill the process group of the command
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,61 @@
+// Package schedule
+// file contains the concurrency limit of tasks.
+package schedule
+
+func (s *Scheduler) setGroupLimit(name string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.groups == nil {
+		s.groups = make(map[string]chan struct{})
+	}
+	if n <= 0 {
+		delete(s.groups, name)
+		return
+	}
+	if c, ok := s.groups[name]; ok && cap(c) == n {
+		return
+	}
+	// the running tasks release the slot to the old channel, so the limit can be changed at any time
+	s.groups[name] = make(chan struct{}, n)
+}
+
+// acquire wait for a slot of the group and the scheduler, the group slot is acquired first,
+// so the queued tasks of a group don't hold the slots of scheduler.
+// The blocked is true if the task waited in queue, it stops waiting when the scheduler context is done.
+func (s *Scheduler) acquire(group string) (release func(), blocked bool, err error) {
+	s.mu.Lock()
+	var slots []chan struct{}
+	if c, ok := s.groups[group]; ok && group != "" {
+		slots = append(slots, c)
+	}
+	if s.slots != nil {
+		slots = append(slots, s.slots)
+	}
+	s.mu.Unlock()
+	acquired := make([]chan struct{}, 0, len(slots))
+	release = func() {
+		for _, c := range acquired {
+			<-c
+		}
+	}
+	for _, c := range slots {
+		select {
+		case c <- struct{}{}:
+		default:
+			blocked = true
+			select {
+			case c <- struct{}{}:
+			case <-s.ctx.Done():
+				release()
+				return nil, blocked, s.ctx.Err()
+			}
+		}
+		acquired = append(acquired, c)
+	}
+	// the queued task is not started if the scheduler context is done while waiting
+	if err := s.ctx.Err(); blocked && err != nil {
+		release()
+		return nil, blocked, err
+	}
+	return release, blocked, nil
+}
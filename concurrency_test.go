@@ -0,0 +1,112 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// peakCounter record the peak number of tasks run at the same time
+type peakCounter struct {
+	current int32
+	peak    int32
+}
+
+func (p *peakCounter) task(d time.Duration) TaskFunc {
+	return func(ctx context.Context) {
+		n := atomic.AddInt32(&p.current, 1)
+		for {
+			peak := atomic.LoadInt32(&p.peak)
+			if n <= peak || atomic.CompareAndSwapInt32(&p.peak, peak, n) {
+				break
+			}
+		}
+		time.Sleep(d)
+		atomic.AddInt32(&p.current, -1)
+	}
+}
+
+func TestScheduler_SetMaxConcurrency(t *testing.T) {
+	s := New(context.Background(), WithMaxConcurrency(2))
+	p := &peakCounter{}
+	for i := 0; i < 6; i++ {
+		s.Task(p.task(10 * time.Millisecond)).EveryMinute()
+	}
+	results, err := s.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), p.peak)
+	var queued int
+	for _, r := range results {
+		if r.QueueWait > 0 {
+			queued++
+		}
+	}
+	assert.True(t, queued >= 4)
+
+	p = &peakCounter{}
+	s.SetMaxConcurrency(0)
+	for i := 0; i < 3; i++ {
+		s.Task(p.task(10 * time.Millisecond)).EveryMinute()
+	}
+	s.Start()
+	assert.Equal(t, int32(3), p.peak)
+}
+
+func TestEvent_Group(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	db := &peakCounter{}
+	other := &peakCounter{}
+	for i := 0; i < 4; i++ {
+		s.Task(db.task(10*time.Millisecond)).EveryMinute().Group("db", 1)
+		s.Task(other.task(10 * time.Millisecond)).EveryMinute()
+	}
+	s.Task(other.task(10*time.Millisecond)).EveryMinute().Group("unlimited", 0)
+	s.Start()
+	assert.Equal(t, int32(1), db.peak)
+	assert.Equal(t, int32(5), other.peak)
+	assert.Equal(t, []string{"Group(db, 1)"}, s.Tasks()[0].Constraints)
+
+	// the limit of group can be changed
+	db = &peakCounter{}
+	for i := 0; i < 4; i++ {
+		s.Task(db.task(10*time.Millisecond)).EveryMinute().Group("db", 2)
+	}
+	s.Start()
+	assert.Equal(t, int32(2), db.peak)
+}
+
+func TestScheduler_acquire_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := New(ctx, WithMaxConcurrency(1))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.Task(func(ctx context.Context) {
+		wg.Done()
+		<-ctx.Done()
+	}).Name("first").EveryMinute().Group("db", 1)
+	s.evaluatePending()
+	wg.Wait()
+	var mark bool
+	s.Task(func(ctx context.Context) {
+		mark = true
+	}).Name("queued").EveryMinute().Group("db", 1)
+	s.Task(func(ctx context.Context) {
+		mark = true
+	}).Name("global").EveryMinute()
+	s.evaluatePending()
+	// wait the other tasks to be queued
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	results, err := s.Wait()
+	assert.False(t, mark)
+	assert.Len(t, results, 3)
+	assert.Error(t, err)
+	assert.Len(t, err.(*RunError).Results, 2)
+	for _, r := range err.(*RunError).Results {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,221 @@
+// Package schedule
+// file contains the parser of standard cron expression.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronExpr the parsed cron expression, every field is a bit set of allowed values
+type CronExpr struct {
+	expr    string
+	Second  uint64
+	Minute  uint64
+	Hour    uint64
+	Dom     uint64
+	Month   uint64
+	Dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronSecond = cronField{name: "second", min: 0, max: 59}
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parse a cron expression with 5 fields (minute hour dom month dow),
+// or 6 fields with a leading second field, or a macro like `@daily` and `@hourly`.
+// Lists (1,2), ranges (1-5), steps (*/5, 1-10/2), month names (JAN) and day names (MON) are supported.
+func ParseCron(expr string) (*CronExpr, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		macro, ok := cronMacros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown macro %q", spec)
+		}
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, found %d in %q", len(fields), expr)
+	}
+	c := &CronExpr{expr: expr}
+	var err error
+	if c.Second, err = cronSecond.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.Minute, err = cronMinute.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.Hour, err = cronHour.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if c.Dom, err = cronDom.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if c.Month, err = cronMonth.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if c.Dow, err = cronDow.parse(fields[5]); err != nil {
+		return nil, err
+	}
+	// 7 is the alias of Sunday
+	if c.Dow&(1<<7) > 0 {
+		c.Dow = c.Dow&^(1<<7) | 1
+	}
+	c.domStar = isCronStar(fields[3])
+	c.dowStar = isCronStar(fields[5])
+	return c, nil
+}
+
+// String return the original cron expression
+func (c *CronExpr) String() string {
+	return c.expr
+}
+
+// Match check the time is matched with the cron expression, include the second field
+func (c *CronExpr) Match(t time.Time) bool {
+	return c.Second&(1<<uint(t.Second())) > 0 && c.matchMinute(t)
+}
+
+func (c *CronExpr) matchMinute(t time.Time) bool {
+	return c.Minute&(1<<uint(t.Minute())) > 0 &&
+		c.Hour&(1<<uint(t.Hour())) > 0 &&
+		c.Month&(1<<uint(t.Month())) > 0 &&
+		c.matchDay(t)
+}
+
+// matchDay follow the cron convention, when both day of month and day of week are restricted,
+// the day is matched if either of them is matched.
+func (c *CronExpr) matchDay(t time.Time) bool {
+	dom := c.Dom&(1<<uint(t.Day())) > 0
+	dow := c.Dow&(1<<uint(t.Weekday())) > 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (c *CronExpr) frequency(now time.Time) *NextTick {
+	next := newNextTick(now)
+	next.Minute = now.Minute()
+	next.Omit = !c.matchMinute(now)
+	// the tick is not on the day if the day never fires
+	if c.Month&(1<<uint(now.Month())) == 0 || !c.matchDay(now) {
+		next.Day = 0
+	}
+	return next
+}
+
+func isCronStar(field string) bool {
+	return field == "*" || field == "?" || strings.HasPrefix(field, "*/")
+}
+
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		b, err := f.parseItem(item)
+		if err != nil {
+			return 0, fmt.Errorf("cron: invalid %s field %q: %w", f.name, field, err)
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+func (f cronField) parseItem(item string) (uint64, error) {
+	if item == "" {
+		return 0, fmt.Errorf("empty value")
+	}
+	start, end, step := f.min, f.max, 1
+	rangeAndStep := strings.Split(item, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("too many slashes in %q", item)
+	}
+	if len(rangeAndStep) == 2 {
+		var err error
+		step, err = strconv.Atoi(rangeAndStep[1])
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", rangeAndStep[1])
+		}
+	}
+	r := rangeAndStep[0]
+	switch {
+	case r == "*" || r == "?":
+		if r == "?" && f.name != cronDom.name && f.name != cronDow.name {
+			return 0, fmt.Errorf("'?' is only allowed in day fields")
+		}
+	default:
+		bounds := strings.Split(r, "-")
+		if len(bounds) > 2 {
+			return 0, fmt.Errorf("too many hyphens in %q", r)
+		}
+		var err error
+		if start, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if len(bounds) == 2 {
+			if end, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+		} else if len(rangeAndStep) == 1 {
+			end = start
+		}
+		if start > end {
+			return 0, fmt.Errorf("range start %d is greater than end %d", start, end)
+		}
+	}
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
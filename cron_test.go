@@ -0,0 +1,102 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "with seconds", expr: "30 */5 9-17 * * 1-5"},
+		{name: "names", expr: "0 0 1 jan,JUL mon-fri"},
+		{name: "question mark", expr: "0 0 ? * SUN"},
+		{name: "macro", expr: "@daily"},
+		{name: "unknown macro", expr: "@often", err: `cron: unknown macro "@often"`},
+		{name: "too few fields", expr: "* * *", err: `cron: expected 5 or 6 fields, found 3 in "* * *"`},
+		{name: "out of range", expr: "60 * * * *", err: `cron: invalid minute field "60": value 60 out of range [0, 59]`},
+		{name: "invalid value", expr: "* x * * *", err: `cron: invalid hour field "x": invalid value "x"`},
+		{name: "invalid step", expr: "*/0 * * * *", err: `cron: invalid minute field "*/0": invalid step "0"`},
+		{name: "too many slashes", expr: "*/2/3 * * * *", err: `cron: invalid minute field "*/2/3": too many slashes in "*/2/3"`},
+		{name: "too many hyphens", expr: "1-2-3 * * * *", err: `cron: invalid minute field "1-2-3": too many hyphens in "1-2-3"`},
+		{name: "reversed range", expr: "* 5-1 * * *", err: `cron: invalid hour field "5-1": range start 5 is greater than end 1`},
+		{name: "empty list item", expr: "1, * * * *", err: `cron: invalid minute field "1,": empty value`},
+		{name: "question mark in minute", expr: "? * * * *", err: `cron: invalid minute field "?": '?' is only allowed in day fields`},
+		{name: "invalid range end", expr: "* * 1-x * *", err: `cron: invalid day of month field "1-x": invalid value "x"`},
+		{name: "invalid second", expr: "61 * * * * *", err: `cron: invalid second field "61": value 61 out of range [0, 59]`},
+		{name: "invalid month", expr: "* * * 13 *", err: `cron: invalid month field "13": value 13 out of range [1, 12]`},
+		{name: "invalid day of week", expr: "* * * * 8", err: `cron: invalid day of week field "8": value 8 out of range [0, 7]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.Nil(t, c)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expr, c.String())
+		})
+	}
+}
+
+func TestCronExpr_Match(t *testing.T) {
+	tests := []struct {
+		expr string
+		time string
+		want bool
+	}{
+		{expr: "* * * * *", time: "2022-10-05 15:30:00", want: true},
+		{expr: "* * * * *", time: "2022-10-05 15:30:01", want: false},
+		{expr: "*/5 9-17 * * 1-5", time: "2022-10-05 15:30:00", want: true},
+		{expr: "*/5 9-17 * * 1-5", time: "2022-10-05 15:31:00", want: false},
+		{expr: "*/5 9-17 * * 1-5", time: "2022-10-05 18:30:00", want: false},
+		{expr: "*/5 9-17 * * 1-5", time: "2022-10-08 15:30:00", want: false},
+		{expr: "10-40/15 * * * *", time: "2022-10-05 15:25:00", want: true},
+		{expr: "10/15 * * * *", time: "2022-10-05 15:55:00", want: true},
+		{expr: "0 0 * * 7", time: "2022-10-09 00:00:00", want: true},
+		{expr: "0 0 * * SUN", time: "2022-10-09 00:00:00", want: true},
+		{expr: "0 0 1 * MON", time: "2022-10-01 00:00:00", want: true},
+		{expr: "0 0 1 * MON", time: "2022-10-03 00:00:00", want: true},
+		{expr: "0 0 1 * MON", time: "2022-10-04 00:00:00", want: false},
+		{expr: "0 0 1 oct *", time: "2022-10-01 00:00:00", want: true},
+		{expr: "0 0 1 oct *", time: "2022-11-01 00:00:00", want: false},
+		{expr: "30 * * * * *", time: "2022-10-05 15:30:30", want: true},
+		{expr: "@hourly", time: "2022-10-05 15:00:00", want: true},
+		{expr: "@yearly", time: "2022-01-01 00:00:00", want: true},
+		{expr: "@weekly", time: "2022-10-05 00:00:00", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.time, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			assert.NoError(t, err)
+			now, _ := time.Parse("2006-01-02 15:04:05", tt.time)
+			assert.Equal(t, tt.want, c.Match(now))
+		})
+	}
+}
+
+func TestScheduler_Cron(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:01")
+	s.Cron("*/5 9-17 * * 1-5")
+	assert.Equal(t, 15, s.Next.Hour)
+	assert.Equal(t, 30, s.Next.Minute)
+	assert.False(t, s.Next.Omit)
+	assert.True(t, s.isTimeMatched())
+	s.Cron("30 * * * * *")
+	assert.True(t, s.isTimeMatched())
+	s.Cron("*/7 * * * *")
+	assert.True(t, s.Next.Omit)
+	s.Cron("* * *")
+	assert.True(t, s.Next.Omit)
+	assert.False(t, s.isTimeMatched())
+}
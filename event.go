@@ -0,0 +1,1276 @@
+// Package schedule
+// file contains the task definition, with its frequency options and constraints.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang-module/carbon/v2"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event the definition of a scheduled task, every frequency chain produces an independent event,
+// so the constraints are scoped to one task only.
+type Event struct {
+	scheduler *Scheduler
+	ctx       context.Context
+	now       time.Time
+	Next      *NextTick
+	limit     *Limit
+	freq      frequency
+	seconds   uint64
+	spread    bool
+	offset    int
+	desc      string
+	expr      string
+	name      string
+	task      Task
+	expiry    time.Duration
+	oneServer bool
+	timeout   time.Duration
+	retries   int
+	backoff   Backoff
+	hooks     hooks
+	output    output
+	group     string
+	groupSize int
+	catchUp   time.Duration
+	catchMode CatchUpMode
+	mu        sync.Mutex
+	evaluated bool
+	running   int
+	repeating int
+	status    Status
+	lastRun   time.Time
+	duration  time.Duration
+	err       error
+	panic     any
+}
+
+// Timezone set the timezone of the task with a new time.Location instance
+func (e *Event) Timezone(loc *time.Location) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.now = e.now.In(loc)
+	if e.freq != nil {
+		e.Next = e.freq(e.now)
+	}
+	return e
+}
+
+// Name set the name of the task, it's the stable identity of the task in logs and registry.
+// The task will be named in registration order like `task-1` if no name set.
+// The error is logged if the name is used by another registered task.
+func (e *Event) Name(name string) *Event {
+	e.mu.Lock()
+	e.name = name
+	e.mu.Unlock()
+	e.scheduler.checkName(e, name)
+	return e
+}
+
+// WithoutOverlapping prevent the task from overlapping, the task will be skipped if the previous one is still running.
+// The lock will be expired after expiry, DefaultMutexExpiry will be used if expiry is not positive.
+func (e *Event) WithoutOverlapping(expiry time.Duration) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if expiry <= 0 {
+		expiry = DefaultMutexExpiry
+	}
+	e.expiry = expiry
+	return e
+}
+
+// OnOneServer run the task on only one server, when the scheduler runs on multiple servers.
+// A lock of the task occurrence is obtained from the LockStore of scheduler, the task will be skipped if it's failed.
+func (e *Event) OnOneServer() *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.oneServer = true
+	return e
+}
+
+// Timeout limit the run time of the task, the context passed to the task will be done after the timeout.
+// The task is reported as timeout if it overruns, no timeout is set if it's not positive.
+func (e *Event) Timeout(d time.Duration) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	e.timeout = d
+	return e
+}
+
+// Retry retry the failing task n times within the same scheduled run, wait the delay of backoff before every retry.
+// Only the tasks which implement TaskE can report their failure, the retry stops when the task context is done.
+func (e *Event) Retry(n int, backoff Backoff) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	if backoff == nil {
+		backoff = ConstantBackoff(0)
+	}
+	e.retries = n
+	e.backoff = backoff
+	return e
+}
+
+// CatchUp run the occurrences of the task missed within the window before now, e.g. the host was down at the time.
+// The last run times are saved in the last run store of scheduler, which should be persistent for crontab,
+// a new task without last run time is not caught up.
+// CatchUp(time.Hour, CatchUpOnce) run the `DailyAt("02:00")` task at 02:30 if it's missed at 02:00.
+func (e *Event) CatchUp(window time.Duration, mode CatchUpMode) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if window < 0 {
+		window = 0
+	}
+	e.catchUp = window
+	e.catchMode = mode
+	return e
+}
+
+// SendOutputTo write the output of task to the file, the file is truncated before every run.
+// The output of command task is written directly, Go task can write to `OutputWriter(ctx)`.
+func (e *Event) SendOutputTo(path string) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.output = output{path: path}
+	return e
+}
+
+// AppendOutputTo append the output of task to the file
+func (e *Event) AppendOutputTo(path string) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.output = output{path: path, append: true}
+	return e
+}
+
+// OutputTo write the output of task to the writer, use `NewRotatingFile` to rotate the output file by size
+func (e *Event) OutputTo(w io.Writer) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.output = output{writer: w}
+	return e
+}
+
+// Group put the task into the named concurrency group, at most n tasks of the group run at the same time,
+// the excess tasks wait in queue. The group is unlimited if n is not positive.
+func (e *Event) Group(name string, n int) *Event {
+	e.mu.Lock()
+	e.group = name
+	e.groupSize = n
+	e.mu.Unlock()
+	e.scheduler.setGroupLimit(name, n)
+	return e
+}
+
+// Before register a hook to run before the task
+func (e *Event) Before(fn HookFunc) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks.before = append(e.hooks.before, fn)
+	return e
+}
+
+// After register a hook to run after the task, whether it succeeds or not
+func (e *Event) After(fn HookFunc) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks.after = append(e.hooks.after, fn)
+	return e
+}
+
+// OnSuccess register a hook to run after the task succeeds
+func (e *Event) OnSuccess(fn HookFunc) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks.success = append(e.hooks.success, fn)
+	return e
+}
+
+// OnFailure register a hook to run after the task fails, panics or timeouts
+func (e *Event) OnFailure(fn HookFunc) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks.failure = append(e.hooks.failure, fn)
+	return e
+}
+
+// Call call a task, the task will run immediately if the frequency and constraints are matched
+func (e *Event) Call(t Task) {
+	e.mu.Lock()
+	e.task = t
+	e.mu.Unlock()
+	e.scheduler.register(e)
+	matched, allowed := e.evaluate()
+	e.scheduler.fire(e, e.now, matched, allowed)
+}
+
+// CallFunc call a task function
+func (e *Event) CallFunc(fn TaskFunc) {
+	e.Call(NewDefaultTask(fn))
+}
+
+// CallE call a task which can report its failure
+func (e *Event) CallE(t TaskE) {
+	e.Call(&errorTask{task: t})
+}
+
+// CallFuncE call a task function which can report its failure
+func (e *Event) CallFuncE(fn TaskFuncE) {
+	e.CallE(NewDefaultTaskE(fn))
+}
+
+// Schedule return the schedule of current frequency and constraints,
+// the pending task definition of scheduler is finished by it like `Call`, so the next chain starts from scratch.
+func (e *Event) Schedule() *Schedule {
+	e.scheduler.finishPending(e)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	l := *e.limit
+	l.DaysOfWeek = append([]time.Weekday(nil), e.limit.DaysOfWeek...)
+	return &Schedule{
+		desc:     e.desc,
+		freq:     e.freq,
+		seconds:  e.seconds,
+		limit:    &l,
+		location: e.now.Location(),
+	}
+}
+
+// NextRuns return the next n fire times of current frequency and constraints from the current time of scheduler clock
+func (e *Event) NextRuns(n int) []time.Time {
+	return e.Schedule().NextRuns(e.scheduler.clock.Now(), n)
+}
+
+// evaluate check the task is due at the time it's defined, every task is evaluated only once this way
+func (e *Event) evaluate() (matched, allowed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.evaluated {
+		return false, false
+	}
+	e.evaluated = true
+	if !e.isTimeMatched() {
+		return false, false
+	}
+	return true, e.checkLimit()
+}
+
+// markEvaluated mark the task evaluated, false is returned if it has been evaluated
+func (e *Event) markEvaluated() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.evaluated {
+		return false
+	}
+	e.evaluated = true
+	return true
+}
+
+func (e *Event) locking() (name string, expiry time.Duration, oneServer, subMinute bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.name, e.expiry, e.oneServer, e.seconds > 0
+}
+
+// subMinute return the bit set of seconds the task runs at within the minute, it's 0 for the task run once a minute at most
+func (e *Event) subMinute() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.seconds
+}
+
+func (e *Event) catchUpPolicy() (name string, window time.Duration, mode CatchUpMode) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.name, e.catchUp, e.catchMode
+}
+
+// runSpec the snapshot of task definition for a run
+type runSpec struct {
+	name    string
+	task    Task
+	timeout time.Duration
+	group   string
+	hooks   hooks
+	output  output
+}
+
+func (e *Event) start(now time.Time) runSpec {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.running++
+	e.status = StatusRunning
+	e.lastRun = now
+	e.duration = 0
+	e.err = nil
+	e.panic = nil
+	return runSpec{
+		name:    e.name,
+		task:    e.task,
+		timeout: e.timeout,
+		group:   e.group,
+		hooks:   e.hooks,
+		output:  e.output,
+	}
+}
+
+// run run the task, retry it with the backoff if it fails
+func (e *Event) run(ctx context.Context, name, runID string, t Task) error {
+	et, ok := t.(*errorTask)
+	if !ok {
+		t.Run(ctx)
+		return nil
+	}
+	e.mu.Lock()
+	retries, backoff := e.retries, e.backoff
+	e.mu.Unlock()
+	err := et.task.Run(ctx)
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		e.scheduler.logAt(LevelWarn, "Task failed, retry it", Field{FieldTask, name}, Field{FieldRunID, runID},
+			Field{"attempt", attempt}, Field{"retries", retries}, Field{FieldError, err})
+		timer := time.NewTimer(backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = et.task.Run(ctx)
+	}
+	return err
+}
+
+func (e *Event) finish(started time.Time, err error, r any, timedOut bool) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.running--
+	e.lastRun = started
+	e.duration = time.Since(started)
+	e.status = StatusSuccess
+	e.err = err
+	e.panic = r
+	if err != nil {
+		e.status = StatusFailed
+	}
+	if timedOut {
+		e.status = StatusTimeout
+	}
+	if r != nil {
+		e.status = StatusPanic
+	}
+	return Result{
+		Name:     e.name,
+		Status:   e.status,
+		Start:    started,
+		End:      started.Add(e.duration),
+		Duration: e.duration,
+		Err:      e.err,
+		Panic:    e.panic,
+	}
+}
+
+func (e *Event) isRunning() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.running > 0
+}
+
+// setRepeating count the pending repetitions of sub-minute task which are waiting for their seconds
+func (e *Event) setRepeating(delta int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.repeating += delta
+}
+
+func (e *Event) isRepeating() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.repeating > 0
+}
+
+func (e *Event) constraints() []string {
+	constraints := e.limit.describe()
+	if e.expiry > 0 {
+		constraints = append(constraints, "WithoutOverlapping("+e.expiry.String()+")")
+	}
+	if e.oneServer {
+		constraints = append(constraints, "OnOneServer()")
+	}
+	if e.timeout > 0 {
+		constraints = append(constraints, "Timeout("+e.timeout.String()+")")
+	}
+	if e.retries > 0 {
+		constraints = append(constraints, "Retry("+strconv.Itoa(e.retries)+")")
+	}
+	if e.group != "" {
+		constraints = append(constraints, "Group("+e.group+", "+strconv.Itoa(e.groupSize)+")")
+	}
+	if e.spread {
+		constraints = append(constraints, "Spread("+strconv.Itoa(e.offset)+"m)")
+	}
+	if e.catchUp > 0 {
+		constraints = append(constraints, "CatchUp("+e.catchUp.String()+", "+e.catchMode.String()+")")
+	}
+	return constraints
+}
+
+func (e *Event) info() TaskInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return TaskInfo{
+		Name:        e.name,
+		Frequency:   e.desc,
+		Cron:        e.expr,
+		Constraints: e.constraints(),
+		Timezone:    e.now.Location().String(),
+		Status:      e.status,
+		LastRun:     e.lastRun,
+		Duration:    e.duration,
+		Err:         e.err,
+		Panic:       e.panic,
+	}
+}
+
+func (e *Event) isTimeMatched() bool {
+	return e.Next.match(e.now)
+}
+
+func (e *Event) checkLimit() bool {
+	return e.limit.check(e.ctx, e.now)
+}
+
+// schedule set the frequency with its description and the equivalent cron expression,
+// the expression is empty if the frequency never fires.
+func (e *Event) schedule(desc, expr string, f frequency) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.desc = desc
+	e.expr = expr
+	e.freq = f
+	e.seconds = 0
+	e.spread = false
+	e.offset = 0
+	e.limit.shift = 0
+	e.Next = f(e.now)
+	return e
+}
+
+// everySeconds set the sub-minute frequency, the task is due every minute,
+// and it's repeated at every interval within the minute.
+func (e *Event) everySeconds(desc string, n int) *Event {
+	e.EveryMinute()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.desc = desc
+	e.expr = "*/" + strconv.Itoa(n) + " * * * * *"
+	if n == 1 {
+		e.expr = "* * * * * *"
+	}
+	for i := 0; i < 60; i += n {
+		e.seconds |= 1 << uint(i)
+	}
+	return e
+}
+
+func (e *Event) days(d ...time.Weekday) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limit.DaysOfWeek = append(e.limit.DaysOfWeek, d...)
+	return e
+}
+
+func timeToMinutes(t string) (hour, minute int) {
+	var err error
+	hm := strings.Split(t, ":")
+	if len(hm) == 2 {
+		hour, err = strconv.Atoi(hm[0])
+		if err == nil {
+			minute, err = strconv.Atoi(hm[1])
+		}
+	}
+	if err != nil {
+		hour = 0
+		minute = 0
+	}
+	return
+}
+
+func joinInts(values []int) string {
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		list = append(list, strconv.Itoa(v))
+	}
+	return strings.Join(list, ", ")
+}
+
+// cronMinutes build the cron expression of the minutes past the hours, the invalid minutes are ignored
+func cronMinutes(minutes []int, hours string) string {
+	list := make([]string, 0, len(minutes))
+	for _, m := range minutes {
+		if m >= 0 && m <= 59 {
+			list = append(list, strconv.Itoa(m))
+		}
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	return strings.Join(list, ",") + " " + hours + " * * *"
+}
+
+// shiftCron delay the cron expressions by the minutes, it's empty if the minute field isn't a list of minutes
+// or the shifted minute overflows the hour.
+func shiftCron(expr string, minutes int) string {
+	if minutes == 0 || expr == "" {
+		return expr
+	}
+	exprs := strings.Split(expr, "; ")
+	for i, v := range exprs {
+		fields := strings.Fields(v)
+		if len(fields) != 5 {
+			return ""
+		}
+		list := strings.Split(fields[0], ",")
+		for j, m := range list {
+			minute, err := strconv.Atoi(m)
+			if err != nil || minute+minutes > 59 {
+				return ""
+			}
+			list[j] = strconv.Itoa(minute + minutes)
+		}
+		fields[0] = strings.Join(list, ",")
+		exprs[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(exprs, "; ")
+}
+
+// cronTimes build the cron expressions of the times (03:00 format) with the day, month and weekday fields.
+// The times with same minute are merged, otherwise the expressions are joined by "; ", the invalid times are ignored.
+func cronTimes(t []string, days string) string {
+	var minutes []int
+	hours := make(map[int][]string)
+	for _, v := range t {
+		hm := strings.Split(v, ":")
+		if len(hm) != 2 {
+			continue
+		}
+		hour, err := strconv.Atoi(hm[0])
+		if err != nil || hour < 0 || hour > 23 {
+			continue
+		}
+		minute, err := strconv.Atoi(hm[1])
+		if err != nil || minute < 0 || minute > 59 {
+			continue
+		}
+		if _, ok := hours[minute]; !ok {
+			minutes = append(minutes, minute)
+		}
+		hours[minute] = append(hours[minute], strconv.Itoa(hour))
+	}
+	exprs := make([]string, 0, len(minutes))
+	for _, minute := range minutes {
+		exprs = append(exprs, strconv.Itoa(minute)+" "+strings.Join(hours[minute], ",")+" "+days)
+	}
+	return strings.Join(exprs, "; ")
+}
+
+func newNextTick(now time.Time) *NextTick {
+	return &NextTick{
+		Year:   now.Year(),
+		Month:  int(now.Month()),
+		Day:    now.Day(),
+		Hour:   now.Hour(),
+		Minute: 0,
+	}
+}
+
+func everyMinutes(n int) frequency {
+	return func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		minute := now.Minute()
+		if minute%n == 0 {
+			next.Minute = minute
+		}
+		return next
+	}
+}
+
+func everyHours(n int) frequency {
+	return func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Omit = true
+		hour := now.Hour()
+		if hour%n == 0 {
+			next.Hour = hour
+			next.Omit = false
+		}
+		return next
+	}
+}
+
+// everyInterval match the minutes which are multiples of the interval from the anchor, the minutes before it never match.
+// The zero anchor is the midnight of 1970-01-01 in the timezone of task.
+func everyInterval(d time.Duration, anchor time.Time) frequency {
+	return func(now time.Time) *NextTick {
+		start := anchor
+		if start.IsZero() {
+			start = time.Date(1970, 1, 1, 0, 0, 0, 0, now.Location())
+		}
+		next := newNextTick(now)
+		next.Omit = true
+		if offset := now.Truncate(time.Minute).Sub(start.Truncate(time.Minute)); offset >= 0 && offset%d == 0 {
+			next.Minute = now.Minute()
+			next.Omit = false
+		}
+		return next
+	}
+}
+
+// hoursAt match the minutes past the hours, the invalid minutes are ignored
+func hoursAt(minutes []int, hours func(hour int) bool) frequency {
+	return func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Omit = true
+		if !hours(now.Hour()) {
+			return next
+		}
+		minute := now.Minute()
+		for _, v := range minutes {
+			if v >= 0 && v == minute {
+				next.Minute = v
+				next.Omit = false
+				break
+			}
+		}
+		return next
+	}
+}
+
+// shiftFrequency delay the frequency by the minutes
+func shiftFrequency(f frequency, minutes int) frequency {
+	d := time.Duration(minutes) * time.Minute
+	return func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Minute = now.Minute()
+		before := now.Add(-d)
+		tick := f(before)
+		next.Omit = !tick.match(before)
+		if !tick.onDay(before) {
+			// the shifted day never fires
+			next.Day = 0
+		}
+		return next
+	}
+}
+
+// SpreadMinute return a stable minute (0-59) hashed from the key, it's the offset used by `Spread`
+func SpreadMinute(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % 60)
+}
+
+// never the frequency never fires
+func never(now time.Time) *NextTick {
+	next := newNextTick(now)
+	next.Day = 0
+	next.Omit = true
+	return next
+}
+
+// EverySecond run task every second, the sub-minute task is repeated until the end of minute,
+// so the scheduler launched by crontab keeps running for the whole minute.
+func (e *Event) EverySecond() *Event {
+	return e.everySeconds("EverySecond()", 1)
+}
+
+// EveryTwoSeconds run task every two seconds
+func (e *Event) EveryTwoSeconds() *Event {
+	return e.everySeconds("EveryTwoSeconds()", 2)
+}
+
+// EveryFiveSeconds run task every five seconds
+func (e *Event) EveryFiveSeconds() *Event {
+	return e.everySeconds("EveryFiveSeconds()", 5)
+}
+
+// EveryTenSeconds run task every ten seconds
+func (e *Event) EveryTenSeconds() *Event {
+	return e.everySeconds("EveryTenSeconds()", 10)
+}
+
+// EveryFifteenSeconds run task every fifteen seconds
+func (e *Event) EveryFifteenSeconds() *Event {
+	return e.everySeconds("EveryFifteenSeconds()", 15)
+}
+
+// EveryTwentySeconds run task every twenty seconds
+func (e *Event) EveryTwentySeconds() *Event {
+	return e.everySeconds("EveryTwentySeconds()", 20)
+}
+
+// EveryThirtySeconds run task every thirty seconds
+func (e *Event) EveryThirtySeconds() *Event {
+	return e.everySeconds("EveryThirtySeconds()", 30)
+}
+
+// EveryMinute run task every minutes
+func (e *Event) EveryMinute() *Event {
+	return e.schedule("EveryMinute()", "* * * * *", func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Minute = now.Minute()
+		return next
+	})
+}
+
+// EveryTwoMinutes run task every two minutes
+func (e *Event) EveryTwoMinutes() *Event {
+	return e.schedule("EveryTwoMinutes()", "*/2 * * * *", everyMinutes(2))
+}
+
+// EveryThreeMinutes run task every three minutes
+func (e *Event) EveryThreeMinutes() *Event {
+	return e.schedule("EveryThreeMinutes()", "*/3 * * * *", everyMinutes(3))
+}
+
+// EveryFourMinutes run task every four minutes
+func (e *Event) EveryFourMinutes() *Event {
+	return e.schedule("EveryFourMinutes()", "*/4 * * * *", everyMinutes(4))
+}
+
+// EveryFiveMinutes run task every five minutes
+func (e *Event) EveryFiveMinutes() *Event {
+	return e.schedule("EveryFiveMinutes()", "*/5 * * * *", everyMinutes(5))
+}
+
+// EveryTenMinutes run the task every ten minutes
+func (e *Event) EveryTenMinutes() *Event {
+	return e.schedule("EveryTenMinutes()", "*/10 * * * *", everyMinutes(10))
+}
+
+// EveryFifteenMinutes run the task every fifteen minutes
+func (e *Event) EveryFifteenMinutes() *Event {
+	return e.schedule("EveryFifteenMinutes()", "*/15 * * * *", everyMinutes(15))
+}
+
+// EveryThirtyMinutes run the task every thirty minutes
+func (e *Event) EveryThirtyMinutes() *Event {
+	return e.schedule("EveryThirtyMinutes()", "*/30 * * * *", everyMinutes(30))
+}
+
+// Hourly run the task every hour
+func (e *Event) Hourly() *Event {
+	return e.schedule("Hourly()", "0 * * * *", newNextTick)
+}
+
+// HourlyAt run the task every hour at some minutes past the hour
+func (e *Event) HourlyAt(t ...int) *Event {
+	return e.hoursAt("HourlyAt", t, "*", func(hour int) bool {
+		return true
+	})
+}
+
+// EveryOddHour run the task every odd hour
+func (e *Event) EveryOddHour() *Event {
+	return e.schedule("EveryOddHour()", "0 1-23/2 * * *", func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Omit = true
+		hour := now.Hour()
+		if hour >= 1 && hour <= 23 && hour%2 != 0 {
+			next.Hour = hour
+			next.Omit = false
+		}
+		return next
+	})
+}
+
+// EveryTwoHours run the task every two hours
+func (e *Event) EveryTwoHours() *Event {
+	return e.schedule("EveryTwoHours()", "0 */2 * * *", everyHours(2))
+}
+
+// EveryThreeHours run the task every three hours
+func (e *Event) EveryThreeHours() *Event {
+	return e.schedule("EveryThreeHours()", "0 */3 * * *", everyHours(3))
+}
+
+// EveryFourHours run the task every four hours
+func (e *Event) EveryFourHours() *Event {
+	return e.schedule("EveryFourHours()", "0 */4 * * *", everyHours(4))
+}
+
+// EveryFiveHours run the task every five hours
+func (e *Event) EveryFiveHours() *Event {
+	return e.schedule("EveryFiveHours()", "0 */5 * * *", everyHours(5))
+}
+
+// EverySixHours run the task every six hours
+func (e *Event) EverySixHours() *Event {
+	return e.schedule("EverySixHours()", "0 */6 * * *", everyHours(6))
+}
+
+// EveryOddHourAt run the task every odd hour at some minutes past the hour
+// EveryOddHourAt(17) run the task at 01:17, 03:17 ... 23:17
+func (e *Event) EveryOddHourAt(t ...int) *Event {
+	return e.hoursAt("EveryOddHourAt", t, "1-23/2", func(hour int) bool {
+		return hour%2 != 0
+	})
+}
+
+// EveryTwoHoursAt run the task every two hours at some minutes past the hour
+func (e *Event) EveryTwoHoursAt(t ...int) *Event {
+	return e.everyHoursAt("EveryTwoHoursAt", 2, t)
+}
+
+// EveryThreeHoursAt run the task every three hours at some minutes past the hour
+func (e *Event) EveryThreeHoursAt(t ...int) *Event {
+	return e.everyHoursAt("EveryThreeHoursAt", 3, t)
+}
+
+// EveryFourHoursAt run the task every four hours at some minutes past the hour
+func (e *Event) EveryFourHoursAt(t ...int) *Event {
+	return e.everyHoursAt("EveryFourHoursAt", 4, t)
+}
+
+// EveryFiveHoursAt run the task every five hours at some minutes past the hour
+func (e *Event) EveryFiveHoursAt(t ...int) *Event {
+	return e.everyHoursAt("EveryFiveHoursAt", 5, t)
+}
+
+// EverySixHoursAt run the task every six hours at some minutes past the hour
+// EverySixHoursAt(17) run the task at 00:17, 06:17, 12:17 and 18:17
+func (e *Event) EverySixHoursAt(t ...int) *Event {
+	return e.everyHoursAt("EverySixHoursAt", 6, t)
+}
+
+func (e *Event) everyHoursAt(method string, n int, t []int) *Event {
+	return e.hoursAt(method, t, "*/"+strconv.Itoa(n), func(hour int) bool {
+		return hour%n == 0
+	})
+}
+
+// hoursAt set the frequency of the minutes past the matched hours, the minutes out of 0-59 never fire and are logged
+func (e *Event) hoursAt(method string, t []int, hours string, match func(hour int) bool) *Event {
+	desc := method + "(" + joinInts(t) + ")"
+	for _, m := range t {
+		if m < 0 || m > 59 {
+			e.scheduler.logAt(LevelError, "Invalid minute of frequency", Field{"frequency", desc})
+			break
+		}
+	}
+	return e.schedule(desc, cronMinutes(t, hours), hoursAt(t, match))
+}
+
+// Spread delay the frequency by a stable minute offset (0-59) hashed from the task name,
+// so the tasks with same frequency don't hit the shared resources at the same minute.
+// It should be called after `Name` and the frequency, the offset is reset if the frequency is changed.
+// The day and time constraints are delayed too, so they are checked against the original fire time.
+// s.Task(fn).Name("backup").EverySixHours().Spread() run the task every six hours at the same minute offset.
+func (e *Event) Spread() *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.freq == nil || e.spread {
+		return e
+	}
+	if e.name == "" {
+		e.scheduler.logAt(LevelWarn, "Spread the task without name, name it first to get a stable offset",
+			Field{"frequency", e.desc})
+	}
+	e.spread = true
+	e.offset = SpreadMinute(e.name)
+	e.freq = shiftFrequency(e.freq, e.offset)
+	e.limit.shift = time.Duration(e.offset) * time.Minute
+	e.expr = shiftCron(e.expr, e.offset)
+	e.Next = e.freq(e.now)
+	return e
+}
+
+// EveryNMinutes run the task every n minutes, the intervals are counted from the anchor and start at it,
+// so they are periodic across hour and day boundaries even if n doesn't divide 60.
+// Without anchor, the task runs on the wall clock minutes which are multiples of n if n divides 60,
+// otherwise the intervals are counted from the midnight of 1970-01-01 in the timezone of task.
+// EveryNMinutes(7, time.Date(2022, 10, 5, 9, 0, 0, 0, time.UTC)) run the task at 09:00, 09:07, 09:14 ...
+func (e *Event) EveryNMinutes(n int, anchor ...time.Time) *Event {
+	return e.everyN("EveryNMinutes", n, time.Minute, anchor)
+}
+
+// EveryNHours run the task every n hours, the intervals are counted from the anchor like `EveryNMinutes`.
+// Without anchor, the task runs at the wall clock hours which are multiples of n if n divides 24,
+// otherwise the intervals are counted from the midnight of 1970-01-01 in the timezone of task.
+// The anchored intervals are fixed durations, so the wall clock times are shifted by the daylight saving time.
+// EveryNHours(8, time.Date(2022, 10, 5, 6, 30, 0, 0, time.UTC)) run the task at 06:30, 14:30, 22:30 ...
+func (e *Event) EveryNHours(n int, anchor ...time.Time) *Event {
+	return e.everyN("EveryNHours", n, time.Hour, anchor)
+}
+
+// everyN set the frequency of every n units from the anchor, the task never fires if n is not positive
+func (e *Event) everyN(method string, n int, unit time.Duration, anchor []time.Time) *Event {
+	desc := method + "(" + strconv.Itoa(n)
+	if len(anchor) > 0 {
+		desc += ", " + anchor[0].Format(time.RFC3339)
+	}
+	desc += ")"
+	if n <= 0 {
+		e.scheduler.logAt(LevelError, "Invalid interval of frequency", Field{"frequency", desc})
+		return e.schedule(desc, "", never)
+	}
+	if len(anchor) == 0 {
+		step := "*"
+		if n > 1 {
+			step = "*/" + strconv.Itoa(n)
+		}
+		switch {
+		case unit == time.Minute && n == 60:
+			return e.schedule(desc, "0 * * * *", everyMinutes(n))
+		case unit == time.Minute && 60%n == 0:
+			return e.schedule(desc, step+" * * * *", everyMinutes(n))
+		case unit == time.Hour && n == 24:
+			return e.schedule(desc, "0 0 * * *", everyHours(n))
+		case unit == time.Hour && 24%n == 0:
+			return e.schedule(desc, "0 "+step+" * * *", everyHours(n))
+		}
+		return e.schedule(desc, "", everyInterval(time.Duration(n)*unit, time.Time{}))
+	}
+	return e.schedule(desc, "", everyInterval(time.Duration(n)*unit, anchor[0]))
+}
+
+// Daily run the task every day at midnight
+func (e *Event) Daily() *Event {
+	return e.schedule("Daily()", "0 0 * * *", func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Hour = 0
+		return next
+	})
+}
+
+// At run the task every day at some time (03:00 format), method alias of dailyAt
+func (e *Event) At(t ...string) *Event {
+	return e.DailyAt(t...)
+}
+
+// DailyAt run the task every day at some time (03:00 format)
+func (e *Event) DailyAt(t ...string) *Event {
+	return e.schedule("DailyAt("+strings.Join(t, ", ")+")", cronTimes(t, "* * *"), dailyAt(t))
+}
+
+func dailyAt(t []string) frequency {
+	return func(now time.Time) *NextTick {
+		next := newNextTick(now)
+		next.Hour = 0
+		next.Minute = 0
+		next.Omit = true
+		next.setTime(now, t)
+		return next
+	}
+}
+
+// TwiceDaily run the task daily at first and second hour
+func (e *Event) TwiceDaily(first, second int) *Event {
+	timeList := make([]string, 0, 2)
+	timeList = append(timeList, strconv.Itoa(first)+":00")
+	timeList = append(timeList, strconv.Itoa(second)+":00")
+	return e.schedule(fmt.Sprintf("TwiceDaily(%d, %d)", first, second), cronTimes(timeList, "* * *"), dailyAt(timeList))
+}
+
+// TwiceDailyAt run the task daily at some time
+// TwiceDailyAt(1, 13, 15) run the task daily at 1:15 & 13:15
+func (e *Event) TwiceDailyAt(first, second, offset int) *Event {
+	timeList := make([]string, 0, 2)
+	timeList = append(timeList, strconv.Itoa(first)+":"+strconv.Itoa(offset))
+	timeList = append(timeList, strconv.Itoa(second)+":"+strconv.Itoa(offset))
+	return e.schedule(fmt.Sprintf("TwiceDailyAt(%d, %d, %d)", first, second, offset), cronTimes(timeList, "* * *"), dailyAt(timeList))
+}
+
+// Weekly run the task every Sunday at 00:00
+func (e *Event) Weekly() *Event {
+	return e.schedule("Weekly()", "0 0 * * 0", func(now time.Time) *NextTick {
+		week := carbon.Time2Carbon(now).StartOfWeek()
+		return &NextTick{
+			Year:   week.Year(),
+			Month:  week.Month(),
+			Day:    week.Day(),
+			Hour:   0,
+			Minute: 0,
+		}
+	})
+}
+
+// WeeklyOn run the task every week on a time
+// WeeklyOn(1, "8:00") run the task every week on Monday at 8:00
+func (e *Event) WeeklyOn(d time.Weekday, t string) *Event {
+	return e.schedule(fmt.Sprintf("WeeklyOn(%s, %s)", d, t), cronTimes([]string{t}, "* * "+strconv.Itoa(int(d))), func(now time.Time) *NextTick {
+		next := &NextTick{
+			Year:   now.Year(),
+			Month:  int(now.Month()),
+			Day:    0,
+			Hour:   0,
+			Minute: 0,
+			Omit:   true,
+		}
+		if now.Weekday() == d {
+			next.Day = now.Day()
+			next.setTime(now, []string{t})
+		}
+		return next
+	})
+}
+
+// Monthly run the task on the first day of every month at 00:00
+func (e *Event) Monthly() *Event {
+	return e.schedule("Monthly()", "0 0 1 * *", func(now time.Time) *NextTick {
+		month := carbon.Time2Carbon(now).StartOfMonth()
+		return &NextTick{
+			Year:   month.Year(),
+			Month:  month.Month(),
+			Day:    month.Day(),
+			Hour:   0,
+			Minute: 0,
+		}
+	})
+}
+
+// MonthlyOn run the task every month on a time
+// MonthlyOn(4, "15:00") run the task every month on the 4th at 15:00
+func (e *Event) MonthlyOn(d int, t string) *Event {
+	return e.schedule(fmt.Sprintf("MonthlyOn(%d, %s)", d, t), cronTimes([]string{t}, strconv.Itoa(d)+" * *"), func(now time.Time) *NextTick {
+		next := &NextTick{
+			Year:   now.Year(),
+			Month:  int(now.Month()),
+			Day:    0,
+			Hour:   0,
+			Minute: 0,
+			Omit:   true,
+		}
+		if now.Day() == d {
+			next.Day = now.Day()
+			next.setTime(now, []string{t})
+		}
+		return next
+	})
+}
+
+// TwiceMonthly run the task monthly on some time
+// TwiceMonthly(1, 16, "13:00") run the task monthly on the 1st and 16th at 13:00
+func (e *Event) TwiceMonthly(first, second int, t string) *Event {
+	days := strconv.Itoa(first) + "," + strconv.Itoa(second) + " * *"
+	return e.schedule(fmt.Sprintf("TwiceMonthly(%d, %d, %s)", first, second, t), cronTimes([]string{t}, days), func(now time.Time) *NextTick {
+		next := &NextTick{
+			Year:   now.Year(),
+			Month:  int(now.Month()),
+			Day:    0,
+			Hour:   0,
+			Minute: 0,
+			Omit:   true,
+		}
+		day := now.Day()
+		if day == first || day == second {
+			next.Day = day
+			next.setTime(now, []string{t})
+		}
+		return next
+	})
+}
+
+// LastDayOfMonth run the task on the last day of the month at a time
+// LastDayOfMonth("15:00") run the task on the last day of the month at 15:00.
+// The standard cron has no last day of month, so it's listed by the description.
+func (e *Event) LastDayOfMonth(t string) *Event {
+	return e.schedule("LastDayOfMonth("+t+")", "", func(now time.Time) *NextTick {
+		next := &NextTick{
+			Year:   now.Year(),
+			Month:  int(now.Month()),
+			Day:    carbon.Time2Carbon(now).EndOfMonth().Day(),
+			Hour:   0,
+			Minute: 0,
+			Omit:   true,
+		}
+		if t != "" {
+			next.setTime(now, []string{t})
+		}
+		return next
+	})
+}
+
+// Quarterly Run the task on the first day of every quarter at 00:00
+func (e *Event) Quarterly() *Event {
+	return e.schedule("Quarterly()", "0 0 1 1,4,7,10 *", func(now time.Time) *NextTick {
+		qs := carbon.Time2Carbon(now).StartOfQuarter()
+		return &NextTick{
+			Year:   now.Year(),
+			Month:  qs.Month(),
+			Day:    qs.Day(),
+			Hour:   0,
+			Minute: 0,
+		}
+	})
+}
+
+// Yearly run the task on the first day of every year at 00:00
+func (e *Event) Yearly() *Event {
+	return e.schedule("Yearly()", "0 0 1 1 *", func(now time.Time) *NextTick {
+		return &NextTick{
+			Year:   now.Year(),
+			Month:  1,
+			Day:    1,
+			Hour:   0,
+			Minute: 0,
+		}
+	})
+}
+
+// YearlyOn Run the task every year on a time
+// YearlyOn(6, 1, "17:00") run the task every year on June 1st at 17:00
+func (e *Event) YearlyOn(m, d int, t string) *Event {
+	days := strconv.Itoa(d) + " " + strconv.Itoa(m) + " *"
+	return e.schedule(fmt.Sprintf("YearlyOn(%d, %d, %s)", m, d, t), cronTimes([]string{t}, days), func(now time.Time) *NextTick {
+		next := &NextTick{
+			Year:   now.Year(),
+			Month:  0,
+			Day:    0,
+			Hour:   0,
+			Minute: 0,
+			Omit:   true,
+		}
+		month := int(now.Month())
+		day := now.Day()
+		if month == m && day == d {
+			next.Month = month
+			next.Day = d
+		}
+		if t != "" {
+			next.setTime(now, []string{t})
+		}
+		return next
+	})
+}
+
+// Cron run the task on a standard cron expression
+// Cron("*/5 9-17 * * 1-5") run the task every five minutes past 9:00 through 17:55 on weekdays.
+// The task of a 6-field expression runs at the matched seconds within the matched minutes, like `EveryNSeconds`.
+// The task will be omitted and an error will be logged if the expression is invalid.
+func (e *Event) Cron(expr string) *Event {
+	c, err := ParseCron(expr)
+	if err != nil {
+		e.scheduler.logAt(LevelError, "Invalid cron expression", Field{FieldError, err})
+		return e.schedule("Cron("+expr+")", "", never)
+	}
+	e.schedule("Cron("+expr+")", expr, c.frequency)
+	if c.Second != 1 {
+		e.mu.Lock()
+		e.seconds = c.Second
+		e.mu.Unlock()
+	}
+	return e
+}
+
+// Weekdays limit the task to weekdays
+func (e *Event) Weekdays() *Event {
+	return e.days(
+		time.Monday,
+		time.Tuesday,
+		time.Wednesday,
+		time.Thursday,
+		time.Friday,
+	)
+}
+
+// Weekends limit the task to weekends
+func (e *Event) Weekends() *Event {
+	return e.days(
+		time.Saturday,
+		time.Sunday,
+	)
+}
+
+// Mondays limit the task to Monday
+func (e *Event) Mondays() *Event {
+	return e.days(
+		time.Monday,
+	)
+}
+
+// Tuesdays limit the task to Tuesday
+func (e *Event) Tuesdays() *Event {
+	return e.days(
+		time.Tuesday,
+	)
+}
+
+// Wednesdays limit the task to Wednesday
+func (e *Event) Wednesdays() *Event {
+	return e.days(
+		time.Wednesday,
+	)
+}
+
+// Thursdays limit the task to Thursday
+func (e *Event) Thursdays() *Event {
+	return e.days(
+		time.Thursday,
+	)
+}
+
+// Fridays limit the task to Friday
+func (e *Event) Fridays() *Event {
+	return e.days(
+		time.Friday,
+	)
+}
+
+// Saturdays limit the task to Saturday
+func (e *Event) Saturdays() *Event {
+	return e.days(
+		time.Saturday,
+	)
+}
+
+// Sundays limit the task to Sunday
+func (e *Event) Sundays() *Event {
+	return e.days(
+		time.Sunday,
+	)
+}
+
+// Days limit the task to specific days
+func (e *Event) Days(d ...time.Weekday) *Event {
+	return e.days(d...)
+}
+
+// Between limit the task to run between start and end time
+func (e *Event) Between(start, end string) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limit.StartTime = start
+	e.limit.EndTime = end
+	e.limit.IsBetween = true
+	return e
+}
+
+// UnlessBetween limit the task to not run between start and end time
+func (e *Event) UnlessBetween(start, end string) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limit.StartTime = start
+	e.limit.EndTime = end
+	e.limit.IsBetween = false
+	return e
+}
+
+// When limit the task based on a truth test
+func (e *Event) When(when WhenFunc) *Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limit.When = when
+	return e
+}
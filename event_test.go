@@ -0,0 +1,62 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvent_Call(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	pending := s.Event
+	s.Weekends().Between("09:00", "10:00").When(func(ctx context.Context) bool {
+		return false
+	}).Daily().CallFunc(func(ctx context.Context) {})
+	assert.NotSame(t, pending, s.Event)
+	assert.Empty(t, s.limit.DaysOfWeek)
+	assert.Empty(t, s.limit.StartTime)
+	assert.Nil(t, s.limit.When)
+	assert.Len(t, pending.limit.DaysOfWeek, 2)
+	assert.Len(t, s.Tasks(), 1)
+	pending.CallFunc(func(ctx context.Context) {})
+	assert.Len(t, s.Tasks(), 1)
+}
+
+func TestEvent_Timezone(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	prc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	e := s.DailyAt("23:30")
+	assert.True(t, e.Next.Omit)
+	e.Timezone(prc)
+	assert.False(t, e.Next.Omit)
+	assert.Equal(t, 23, e.Next.Hour)
+	e.CallFunc(func(ctx context.Context) {})
+	assert.Equal(t, time.UTC, s.now.Location())
+}
+
+func TestEvent_Timeout(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	var err error
+	s.EveryMinute().Name("honest").Timeout(10 * time.Millisecond).CallFunc(func(ctx context.Context) {
+		<-ctx.Done()
+		err = ctx.Err()
+	})
+	s.EveryMinute().Name("quick").Timeout(time.Minute).CallFunc(func(ctx context.Context) {})
+	s.EveryMinute().Name("unlimited").Timeout(-time.Second).CallFunc(func(ctx context.Context) {
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+	s.Start()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	tasks := s.Tasks()
+	assert.Equal(t, StatusTimeout, tasks[0].Status)
+	assert.Equal(t, []string{"Timeout(10ms)"}, tasks[0].Constraints)
+	assert.Equal(t, StatusSuccess, tasks[1].Status)
+	assert.Equal(t, StatusSuccess, tasks[2].Status)
+	assert.Empty(t, tasks[2].Constraints)
+}
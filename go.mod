@@ -4,6 +4,7 @@ go 1.19
 
 require (
 	github.com/golang-module/carbon/v2 v2.1.9
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/stretchr/testify v1.7.0
 )
 
@@ -0,0 +1,254 @@
+// Package schedule
+// file contains the run history, which is recorded in a JSON lines file or a database table.
+package schedule
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// HistoryRecord the record of a task run in history
+type HistoryRecord struct {
+	Task      string    `json:"task"`
+	RunID     string    `json:"run_id"`
+	Scheduled time.Time `json:"scheduled"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Host      string    `json:"host"`
+}
+
+// HistoryStore the store of run history, every finished task run is recorded
+type HistoryStore interface {
+	// Record save the record of a task run
+	Record(ctx context.Context, r HistoryRecord) error
+	// Last return the latest run of the task, nil is returned if the task has never run
+	Last(ctx context.Context, task string) (*HistoryRecord, error)
+	// Recent return at most n latest runs of the task, the latest run is the first
+	Recent(ctx context.Context, task string, n int) ([]HistoryRecord, error)
+}
+
+// newHistoryRecord create the history record of the task run result
+func newHistoryRecord(r Result) HistoryRecord {
+	h := HistoryRecord{
+		Task:      r.Name,
+		RunID:     r.RunID,
+		Scheduled: r.Scheduled,
+		Start:     r.Start,
+		End:       r.End,
+		Status:    r.Status,
+		Host:      hostname(),
+	}
+	switch {
+	case r.Panic != nil:
+		h.Error = fmt.Sprint(r.Panic)
+	case r.Err != nil:
+		h.Error = r.Err.Error()
+	}
+	return h
+}
+
+// hostname return the host name of current server
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
+
+// FileHistoryStore the history store base on a JSON lines file, a record is appended to the file per run.
+// The queries scan the whole file, rotate it by an external tool if it grows too large.
+type FileHistoryStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileHistoryStore create instance of history store with the path of JSON lines file
+func NewFileHistoryStore(path string) *FileHistoryStore {
+	return &FileHistoryStore{path: path}
+}
+
+// Record append the record to the file
+func (f *FileHistoryStore) Record(ctx context.Context, r HistoryRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Last return the latest run of the task
+func (f *FileHistoryStore) Last(ctx context.Context, task string) (*HistoryRecord, error) {
+	runs, err := f.Recent(ctx, task, 1)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+// Recent return at most n latest runs of the task
+func (f *FileHistoryStore) Recent(ctx context.Context, task string, n int) ([]HistoryRecord, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var runs []HistoryRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r HistoryRecord
+		if err = json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		if r.Task != task {
+			continue
+		}
+		runs = append(runs, r)
+		if len(runs) > n {
+			runs = runs[1:]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
+}
+
+// SQLHistoryStore the history store base on a database table, the times are saved as unix nanoseconds.
+type SQLHistoryStore struct {
+	db          *sql.DB
+	table       string
+	Placeholder Placeholder
+}
+
+// NewSQLHistoryStore create instance of sql history store with the table name, `?` bind variable is used by default
+func NewSQLHistoryStore(db *sql.DB, table string) *SQLHistoryStore {
+	return &SQLHistoryStore{
+		db:          db,
+		table:       table,
+		Placeholder: QuestionPlaceholder,
+	}
+}
+
+// Migrate create the history table if not exists, an index on (task, started_at) is recommended for large history.
+func (s *SQLHistoryStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+" ("+
+		"run_id VARCHAR(32) NOT NULL, "+
+		"task VARCHAR(255) NOT NULL, "+
+		"scheduled_at BIGINT NOT NULL, "+
+		"started_at BIGINT NOT NULL, "+
+		"ended_at BIGINT NOT NULL, "+
+		"status VARCHAR(16) NOT NULL, "+
+		"error TEXT NOT NULL, "+
+		"host VARCHAR(255) NOT NULL)")
+	return err
+}
+
+// Record insert the record to the table
+func (s *SQLHistoryStore) Record(ctx context.Context, r HistoryRecord) error {
+	_, err := s.db.ExecContext(ctx, s.bind("INSERT INTO "+s.table+
+		" (run_id, task, scheduled_at, started_at, ended_at, status, error, host) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		r.RunID, r.Task, unixNano(r.Scheduled), unixNano(r.Start), unixNano(r.End), string(r.Status), r.Error, r.Host)
+	return err
+}
+
+// Last return the latest run of the task
+func (s *SQLHistoryStore) Last(ctx context.Context, task string) (*HistoryRecord, error) {
+	runs, err := s.Recent(ctx, task, 1)
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+// Recent return at most n latest runs of the task
+func (s *SQLHistoryStore) Recent(ctx context.Context, task string, n int) ([]HistoryRecord, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, s.bind("SELECT run_id, task, scheduled_at, started_at, ended_at, status, error, host FROM "+
+		s.table+" WHERE task = ? ORDER BY started_at DESC LIMIT ?"), task, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []HistoryRecord
+	for rows.Next() {
+		var r HistoryRecord
+		var scheduled, start, end int64
+		var status string
+		if err = rows.Scan(&r.RunID, &r.Task, &scheduled, &start, &end, &status, &r.Error, &r.Host); err != nil {
+			return nil, err
+		}
+		r.Scheduled, r.Start, r.End = fromUnixNano(scheduled), fromUnixNano(start), fromUnixNano(end)
+		r.Status = Status(status)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+func (s *SQLHistoryStore) bind(query string) string {
+	if s.Placeholder == nil {
+		return query
+	}
+	return bindSQL(query, s.Placeholder)
+}
+
+// unixNano convert the time to unix nanoseconds, the zero time is 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano convert the unix nanoseconds to time, 0 is the zero time
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// saveHistory record the result of task run to the history store,
+// it's recorded even if the scheduler is stopping, so the context of scheduler is not used.
+func (s *Scheduler) saveHistory(r Result) {
+	if s.history == nil {
+		return
+	}
+	if err := s.history.Record(context.Background(), newHistoryRecord(r)); err != nil {
+		s.logAt(LevelError, "Failed to record the history of task", Field{FieldTask, r.Name}, Field{FieldRunID, r.RunID},
+			Field{FieldError, err})
+	}
+}
@@ -0,0 +1,139 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testHistoryStore(t *testing.T, store HistoryStore) {
+	ctx := context.Background()
+	last, err := store.Last(ctx, "report")
+	assert.NoError(t, err)
+	assert.Nil(t, last)
+
+	start := time.Date(2022, 10, 5, 15, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, store.Record(ctx, HistoryRecord{
+			Task:      "report",
+			RunID:     "run-" + string(rune('a'+i)),
+			Scheduled: start.Add(time.Duration(i) * time.Hour),
+			Start:     start.Add(time.Duration(i)*time.Hour + time.Second),
+			End:       start.Add(time.Duration(i)*time.Hour + 2*time.Second),
+			Status:    StatusSuccess,
+			Host:      "web-1",
+		}))
+	}
+	assert.NoError(t, store.Record(ctx, HistoryRecord{Task: "cleanup", RunID: "run-d", Start: start, Status: StatusFailed, Error: "broken"}))
+
+	last, err = store.Last(ctx, "report")
+	assert.NoError(t, err)
+	assert.Equal(t, "run-c", last.RunID)
+	assert.True(t, start.Add(2*time.Hour).Equal(last.Scheduled))
+	assert.True(t, start.Add(2*time.Hour+2*time.Second).Equal(last.End))
+	assert.Equal(t, StatusSuccess, last.Status)
+	assert.Equal(t, "web-1", last.Host)
+
+	runs, err := store.Recent(ctx, "report", 2)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "run-c", runs[0].RunID)
+	assert.Equal(t, "run-b", runs[1].RunID)
+	runs, err = store.Recent(ctx, "report", 10)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 3)
+	runs, err = store.Recent(ctx, "report", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+
+	last, err = store.Last(ctx, "cleanup")
+	assert.NoError(t, err)
+	assert.Equal(t, "broken", last.Error)
+	assert.True(t, last.Scheduled.IsZero())
+}
+
+func TestFileHistoryStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	testHistoryStore(t, NewFileHistoryStore(path))
+
+	assert.NoError(t, os.WriteFile(path, []byte("{\n"), 0644))
+	_, err := NewFileHistoryStore(path).Last(context.Background(), "report")
+	assert.Error(t, err)
+	store := NewFileHistoryStore(filepath.Join(t.TempDir(), "missing", "history.jsonl"))
+	assert.Error(t, store.Record(context.Background(), HistoryRecord{Task: "report"}))
+	assert.NoError(t, os.Mkdir(path+".d", 0755))
+	_, err = NewFileHistoryStore(path+".d").Recent(context.Background(), "report", 1)
+	assert.Error(t, err)
+}
+
+func TestSQLHistoryStore(t *testing.T) {
+	db := openSQLite(t, "history.db")
+	store := NewSQLHistoryStore(db, "schedule_history")
+	require.NoError(t, store.Migrate(context.Background()))
+	assert.NoError(t, store.Migrate(context.Background()))
+	testHistoryStore(t, store)
+
+	missing := NewSQLHistoryStore(db, "missing")
+	missing.Placeholder = nil
+	assert.Error(t, missing.Record(context.Background(), HistoryRecord{Task: "report"}))
+	_, err := missing.Last(context.Background(), "report")
+	assert.Error(t, err)
+}
+
+func TestScheduler_history(t *testing.T) {
+	store := NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	now := time.Date(2022, 10, 5, 15, 30, 0, 0, time.UTC)
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)), WithHistoryStore(store))
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("broken").EveryMinute()
+	s.Task(func(ctx context.Context) {
+		panic("task panic")
+	}).Name("panic").EveryMinute()
+	s.Task(func(ctx context.Context) {}).Name("report").EveryMinute()
+	_, err := s.Wait()
+	assert.Error(t, err)
+
+	ctx := context.Background()
+	last, err := store.Last(ctx, "broken")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusFailed, last.Status)
+	assert.Equal(t, "broken", last.Error)
+	assert.True(t, now.Equal(last.Scheduled))
+	assert.Equal(t, hostname(), last.Host)
+	assert.NotEmpty(t, last.RunID)
+	last, err = store.Last(ctx, "panic")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPanic, last.Status)
+	assert.Equal(t, "task panic", last.Error)
+	last, err = store.Last(ctx, "report")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSuccess, last.Status)
+	assert.Empty(t, last.Error)
+	assert.False(t, last.Start.IsZero())
+
+	logger := &recordLogger{}
+	s = New(context.Background(), WithStructuredLogger(logger),
+		WithHistoryStore(NewFileHistoryStore(filepath.Join(t.TempDir(), "missing", "history.jsonl"))))
+	s.Task(func(ctx context.Context) {}).Name("report").EveryMinute()
+	s.Start()
+	assert.NotNil(t, logger.find("Failed to record the history of task"))
+}
+
+func TestEvent_finish(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	e := s.Task(func(ctx context.Context) {})
+	e.start(time.Now())
+	r := e.finish(time.Now(), errors.New("broken"), nil, false)
+	assert.Equal(t, StatusFailed, r.Status)
+	e.start(time.Now())
+	r = e.finish(time.Now(), nil, nil, false)
+	assert.Equal(t, StatusSuccess, r.Status)
+	assert.Nil(t, r.Err)
+}
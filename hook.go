@@ -0,0 +1,48 @@
+// Package schedule
+// file contains the lifecycle hooks of tasks.
+package schedule
+
+import (
+	"context"
+)
+
+// HookFunc the lifecycle callback of task, it receives the record of the task run.
+// The record passed to the before hooks contains the name and start time only.
+type HookFunc func(ctx context.Context, r Result)
+
+// hooks the lifecycle hook chains
+type hooks struct {
+	before  []HookFunc
+	after   []HookFunc
+	success []HookFunc
+	failure []HookFunc
+}
+
+// wrap merge the hooks of task into the scheduler-wide hooks, the scheduler-wide hooks run around the task hooks
+func (h hooks) wrap(task hooks) hooks {
+	return hooks{
+		before:  concatHooks(h.before, task.before),
+		after:   concatHooks(task.after, h.after),
+		success: concatHooks(task.success, h.success),
+		failure: concatHooks(task.failure, h.failure),
+	}
+}
+
+func concatHooks(a, b []HookFunc) []HookFunc {
+	return append(append(make([]HookFunc, 0, len(a)+len(b)), a...), b...)
+}
+
+// runHooks run the hooks one by one, the panic of a hook is recovered and logged
+func (s *Scheduler) runHooks(fns []HookFunc, r Result) {
+	for _, fn := range fns {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					s.logAt(LevelError, "Recovering hook of task from panic",
+						Field{FieldTask, r.Name}, Field{FieldRunID, r.RunID}, Field{"panic", p})
+				}
+			}()
+			fn(s.ctx, r)
+		}()
+	}
+}
@@ -0,0 +1,73 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvent_hooks(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	var mu sync.Mutex
+	var calls []string
+	hook := func(name string) HookFunc {
+		return func(ctx context.Context, r Result) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name+":"+r.Name+":"+string(r.Status))
+		}
+	}
+	s.BeforeEach(hook("global-before")).
+		AfterEach(hook("global-after")).
+		OnEachSuccess(hook("global-success")).
+		OnEachFailure(hook("global-failure"))
+	s.Task(func(ctx context.Context) {}).Name("ok").EveryMinute().
+		Before(hook("before")).After(hook("after")).OnSuccess(hook("success")).OnFailure(hook("failure"))
+	s.Start()
+	assert.Equal(t, []string{
+		"global-before:ok:running",
+		"before:ok:running",
+		"success:ok:success",
+		"global-success:ok:success",
+		"after:ok:success",
+		"global-after:ok:success",
+	}, calls)
+
+	calls = nil
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("failed").EveryMinute().OnSuccess(hook("success")).OnFailure(hook("failure"))
+	s.Start()
+	assert.Equal(t, []string{
+		"global-before:failed:running",
+		"failure:failed:failed",
+		"global-failure:failed:failed",
+		"global-after:failed:failed",
+	}, calls)
+}
+
+func TestEvent_hooks_record(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	var record Result
+	var hooked bool
+	s.Task(func(ctx context.Context) {
+		panic("boom")
+	}).EveryMinute().Before(func(ctx context.Context, r Result) {
+		panic("hook")
+	}).OnFailure(func(ctx context.Context, r Result) {
+		record = r
+	}).After(func(ctx context.Context, r Result) {
+		hooked = true
+	})
+	s.Start()
+	assert.True(t, hooked)
+	assert.Equal(t, "task-1", record.Name)
+	assert.Equal(t, StatusPanic, record.Status)
+	assert.Equal(t, "boom", record.Panic)
+	assert.False(t, record.Start.IsZero())
+	assert.Equal(t, record.Start.Add(record.Duration), record.End)
+}
@@ -0,0 +1,81 @@
+// Package schedule
+// file contains the listing of registered tasks, like the `schedule:list` command.
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ListEntry the registered task in the list
+type ListEntry struct {
+	Name        string    `json:"name"`
+	Cron        string    `json:"cron"`
+	Frequency   string    `json:"frequency"`
+	Constraints []string  `json:"constraints"`
+	Timezone    string    `json:"timezone"`
+	NextDue     time.Time `json:"next_due"`
+}
+
+// Entries return the registered tasks with their cron expressions and next due times.
+// The next due time is zero if the task never fires in the lookahead window.
+func (s *Scheduler) Entries() []ListEntry {
+	now := s.clock.Now()
+	events := s.registered()
+	entries := make([]ListEntry, 0, len(events))
+	for _, e := range events {
+		info := e.info()
+		constraints := info.Constraints
+		if constraints == nil {
+			constraints = []string{}
+		}
+		entries = append(entries, ListEntry{
+			Name:        info.Name,
+			Cron:        info.Cron,
+			Frequency:   info.Frequency,
+			Constraints: constraints,
+			Timezone:    info.Timezone,
+			NextDue:     e.Schedule().Next(now),
+		})
+	}
+	return entries
+}
+
+// List write the registered tasks as a table to w, it's handy for a `--list` flag:
+//
+//	if *list {
+//		_ = s.List(os.Stdout)
+//		return
+//	}
+//	s.Start()
+func (s *Scheduler) List(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tCRON\tCONSTRAINTS\tTIMEZONE\tNEXT DUE")
+	for _, entry := range s.Entries() {
+		cron := entry.Cron
+		if cron == "" {
+			cron = entry.Frequency
+		}
+		constraints := strings.Join(entry.Constraints, " ")
+		if constraints == "" {
+			constraints = "-"
+		}
+		next := "never"
+		if !entry.NextDue.IsZero() {
+			next = entry.NextDue.Format("2006-01-02 15:04 -0700")
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.Name, cron, constraints, entry.Timezone, next)
+	}
+	return tw.Flush()
+}
+
+// ListJSON write the registered tasks as a JSON array to w
+func (s *Scheduler) ListJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Entries())
+}
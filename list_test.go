@@ -0,0 +1,97 @@
+// Package schedule
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvent_cron(t *testing.T) {
+	cases := map[string]func(s *Scheduler) *Event{
+		"* * * * *":              func(s *Scheduler) *Event { return s.EveryMinute() },
+		"*/5 * * * *":            func(s *Scheduler) *Event { return s.EveryFiveMinutes() },
+		"0 * * * *":              func(s *Scheduler) *Event { return s.Hourly() },
+		"15,45 * * * *":          func(s *Scheduler) *Event { return s.HourlyAt(15, 45, 60) },
+		"0 1-23/2 * * *":         func(s *Scheduler) *Event { return s.EveryOddHour() },
+		"0 */6 * * *":            func(s *Scheduler) *Event { return s.EverySixHours() },
+		"0 0 * * *":              func(s *Scheduler) *Event { return s.Daily() },
+		"30 9,18 * * *":          func(s *Scheduler) *Event { return s.DailyAt("09:30", "18:30", "25:00") },
+		"0 1,13 * * *":           func(s *Scheduler) *Event { return s.TwiceDaily(1, 13) },
+		"15 1,13 * * *":          func(s *Scheduler) *Event { return s.TwiceDailyAt(1, 13, 15) },
+		"0 9 * * *; 30 17 * * *": func(s *Scheduler) *Event { return s.DailyAt("09:00", "17:30") },
+		"0 0 * * 0":              func(s *Scheduler) *Event { return s.Weekly() },
+		"0 8 * * 1":              func(s *Scheduler) *Event { return s.WeeklyOn(time.Monday, "8:00") },
+		"0 0 1 * *":              func(s *Scheduler) *Event { return s.Monthly() },
+		"0 15 4 * *":             func(s *Scheduler) *Event { return s.MonthlyOn(4, "15:00") },
+		"0 13 1,16 * *":          func(s *Scheduler) *Event { return s.TwiceMonthly(1, 16, "13:00") },
+		"0 0 1 1,4,7,10 *":       func(s *Scheduler) *Event { return s.Quarterly() },
+		"0 0 1 1 *":              func(s *Scheduler) *Event { return s.Yearly() },
+		"0 17 1 6 *":             func(s *Scheduler) *Event { return s.YearlyOn(6, 1, "17:00") },
+		"*/10 9-17 * * 1-5":      func(s *Scheduler) *Event { return s.Cron("*/10 9-17 * * 1-5") },
+		"":                       func(s *Scheduler) *Event { return s.Cron("invalid") },
+	}
+	for expr, f := range cases {
+		e := f(NewScheduler(context.Background(), time.UTC))
+		assert.Equal(t, expr, e.expr, e.desc)
+		// the listed expressions can be parsed back
+		for _, part := range strings.Split(expr, "; ") {
+			if part != "" {
+				_, err := ParseCron(part)
+				assert.NoError(t, err, e.desc)
+			}
+		}
+	}
+	s := NewScheduler(context.Background(), time.UTC)
+	assert.Equal(t, "", s.HourlyAt(60).expr)
+	// the last day of month can't be expressed in standard cron
+	assert.Equal(t, "", s.LastDayOfMonth("15:00").expr)
+	assert.Equal(t, "", s.LastDayOfMonth("").expr)
+}
+
+func TestScheduler_List(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:00")
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	s.Name("report").DailyAt("09:30").Weekdays().When(func(ctx context.Context) bool {
+		return true
+	}).CallFunc(func(ctx context.Context) {})
+	s.Name("cleanup").EveryFiveMinutes().CallFunc(func(ctx context.Context) {})
+	s.Name("never").Cron("invalid").CallFunc(func(ctx context.Context) {})
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.List(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, []string{"NAME", "CRON", "CONSTRAINTS", "TIMEZONE", "NEXT", "DUE"}, strings.Fields(lines[0]))
+	assert.Contains(t, lines[1], "report")
+	assert.Contains(t, lines[1], "30 9 * * *")
+	assert.Contains(t, lines[1], "Days(Monday, Tuesday, Wednesday, Thursday, Friday) When()")
+	assert.Contains(t, lines[1], "2022-10-06 09:30 +0000")
+	assert.Contains(t, lines[2], "*/5 * * * *")
+	assert.Contains(t, lines[2], " - ")
+	assert.Contains(t, lines[2], "2022-10-05 15:35 +0000")
+	assert.Contains(t, lines[3], "Cron(invalid)")
+	assert.True(t, strings.HasSuffix(lines[3], "never"))
+}
+
+func TestScheduler_ListJSON(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:00")
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	s.Name("cleanup").Hourly().CallFunc(func(ctx context.Context) {})
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.ListJSON(&buf))
+	var entries []map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "cleanup", entries[0]["name"])
+	assert.Equal(t, "0 * * * *", entries[0]["cron"])
+	assert.Equal(t, "Hourly()", entries[0]["frequency"])
+	assert.Equal(t, []any{}, entries[0]["constraints"])
+	assert.Equal(t, "UTC", entries[0]["timezone"])
+	assert.Equal(t, "2022-10-05T16:00:00Z", entries[0]["next_due"])
+}
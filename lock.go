@@ -0,0 +1,37 @@
+// Package schedule
+// file contains the lock store to run a task on one server only.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OneServerLockExpiry the expiry of the lock obtained by the tasks run on one server
+const OneServerLockExpiry = time.Hour
+
+// ErrNoLockStore the error of the task run on one server without lock store
+var ErrNoLockStore = errors.New("schedule: no lock store for the task run on one server")
+
+// LockStore the distributed lock store interface for the tasks run on one server
+type LockStore interface {
+	// Obtain try to obtain the lock of key, it should return false if the lock is held by other server
+	Obtain(ctx context.Context, key string, expiry time.Duration) (bool, error)
+}
+
+// lockOwner the owner of the lock, identify the server and process
+func lockOwner() string {
+	return hostname() + ":" + strconv.Itoa(os.Getpid())
+}
+
+// oneServerKey the lock key of a task occurrence, it's unique per task per minute, or per second for sub-minute task
+func oneServerKey(name string, at time.Time, subMinute bool) string {
+	layout := "200601021504"
+	if subMinute {
+		layout += "05"
+	}
+	return "schedule:" + name + ":" + at.UTC().Format(layout)
+}
@@ -0,0 +1,129 @@
+// Package schedule
+// file contains the lock store base on redis protocol.
+package schedule
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRedisTimeout the default dial and io timeout of redis lock store
+const DefaultRedisTimeout = 5 * time.Second
+
+// RedisLockStore the lock store base on redis protocol, it works with any server compatible with redis `SET NX PX`.
+// A new connection is created for every lock, because the scheduler obtains a few locks per minute only.
+type RedisLockStore struct {
+	Addr     string
+	Password string
+	DB       int
+	Timeout  time.Duration
+}
+
+// NewRedisLockStore create instance of redis lock store with the address like `127.0.0.1:6379`
+func NewRedisLockStore(addr string) *RedisLockStore {
+	return &RedisLockStore{
+		Addr:    addr,
+		Timeout: DefaultRedisTimeout,
+	}
+}
+
+// Obtain try to obtain the lock of key with `SET key owner NX PX expiry`
+func (r *RedisLockStore) Obtain(ctx context.Context, key string, expiry time.Duration) (bool, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultRedisTimeout
+	}
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.Addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		return false, err
+	}
+	c := &redisConn{rw: bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))}
+	if r.Password != "" {
+		if _, err = c.do("AUTH", r.Password); err != nil {
+			return false, err
+		}
+	}
+	if r.DB != 0 {
+		if _, err = c.do("SELECT", strconv.Itoa(r.DB)); err != nil {
+			return false, err
+		}
+	}
+	ms := expiry.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	reply, err := c.do("SET", key, lockOwner(), "NX", "PX", strconv.FormatInt(ms, 10))
+	if errors.Is(err, errRedisNil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return reply == "OK", nil
+}
+
+var errRedisNil = errors.New("redis: nil reply")
+
+type redisConn struct {
+	rw *bufio.ReadWriter
+}
+
+// do send a command and read the reply with RESP protocol
+func (c *redisConn) do(args ...string) (string, error) {
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	if _, err := c.rw.WriteString(b.String()); err != nil {
+		return "", err
+	}
+	if err := c.rw.Flush(); err != nil {
+		return "", err
+	}
+	line, err := c.rw.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("redis: empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("redis: %s", line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("redis: invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return "", errRedisNil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(c.rw, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	default:
+		return "", fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,194 @@
+// Package schedule
+package schedule
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis the in-memory stand-in of redis server, it supports AUTH, SELECT and SET NX PX only
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	mu       sync.Mutex
+	keys     map[string]time.Time
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	r := &fakeRedis{listener: l, password: password, keys: make(map[string]time.Time)}
+	go r.serve()
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	return r
+}
+
+func (r *fakeRedis) addr() string {
+	return r.listener.Addr().String()
+}
+
+func (r *fakeRedis) history() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.commands...)
+}
+
+func (r *fakeRedis) serve() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+		go r.handle(conn)
+	}
+}
+
+func (r *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := r.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		r.commands = append(r.commands, strings.Join(args, " "))
+		r.mu.Unlock()
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			authed = args[1] == r.password
+			reply = "+OK\r\n"
+			if !authed {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case "SELECT":
+			reply = "+OK\r\n"
+		case "SET":
+			if !authed {
+				reply = "-NOAUTH Authentication required.\r\n"
+				break
+			}
+			ms, _ := strconv.Atoi(args[5])
+			r.mu.Lock()
+			if expiresAt, ok := r.keys[args[1]]; ok && time.Now().Before(expiresAt) {
+				reply = "$-1\r\n"
+			} else {
+				r.keys[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+				reply = "+OK\r\n"
+			}
+			r.mu.Unlock()
+		case "PING":
+			reply = "$4\r\nPONG\r\n"
+		case "INCR":
+			reply = ":1\r\n"
+		default:
+			reply = "*0\r\n"
+		}
+		if _, err = io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func TestRedisLockStore_Obtain(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	store := NewRedisLockStore(server.addr())
+	store.Password = "secret"
+	store.DB = 2
+	ctx := context.Background()
+	ok, err := store.Obtain(ctx, "schedule:task:202210051530", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Obtain(ctx, "schedule:task:202210051530", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = store.Obtain(ctx, "schedule:task:202210051531", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	commands := server.history()
+	assert.Equal(t, "AUTH secret", commands[0])
+	assert.Equal(t, "SELECT 2", commands[1])
+	assert.True(t, strings.HasPrefix(commands[2], "SET schedule:task:202210051530 "))
+	assert.True(t, strings.HasSuffix(commands[2], " NX PX 60000"))
+	assert.True(t, strings.HasSuffix(commands[8], " NX PX 1"))
+}
+
+func TestRedisLockStore_error(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	store := NewRedisLockStore(server.addr())
+	store.Password = "wrong"
+	ok, err := store.Obtain(context.Background(), "key", time.Minute)
+	assert.EqualError(t, err, "redis: WRONGPASS invalid password")
+	assert.False(t, ok)
+
+	store.Password = ""
+	store.Timeout = 0
+	ok, err = store.Obtain(context.Background(), "key", time.Minute)
+	assert.EqualError(t, err, "redis: NOAUTH Authentication required.")
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	ok, err = store.Obtain(ctx, "key", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedisLockStore_closed(t *testing.T) {
+	server := newFakeRedis(t, "")
+	addr := server.addr()
+	assert.NoError(t, server.listener.Close())
+	ok, err := NewRedisLockStore(addr).Obtain(context.Background(), "key", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedisConn_do(t *testing.T) {
+	server := newFakeRedis(t, "")
+	conn, err := net.Dial("tcp", server.addr())
+	assert.NoError(t, err)
+	defer conn.Close()
+	c := &redisConn{rw: bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))}
+	reply, err := c.do("PING")
+	assert.NoError(t, err)
+	assert.Equal(t, "PONG", reply)
+	reply, err = c.do("INCR", "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", reply)
+	_, err = c.do("KEYS", "*")
+	assert.EqualError(t, err, `redis: unexpected reply "*0"`)
+}
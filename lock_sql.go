@@ -0,0 +1,93 @@
+// Package schedule
+// file contains the lock store base on database/sql.
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Placeholder the function to generate the nth (start from 1) bind variable of sql statement
+type Placeholder func(n int) string
+
+// QuestionPlaceholder the `?` bind variable, used by MySQL and SQLite
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
+
+// DollarPlaceholder the `$1` bind variable, used by PostgreSQL
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// bindSQL replace the `?` in query with the placeholder
+func bindSQL(query string, p Placeholder) string {
+	parts := strings.Split(query, "?")
+	var b strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteString(p(i))
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// SQLLockStore the lock store base on a database table, the lock key is the primary key of table.
+type SQLLockStore struct {
+	db          *sql.DB
+	table       string
+	Placeholder Placeholder
+}
+
+// NewSQLLockStore create instance of sql lock store with the table name, `?` bind variable is used by default
+func NewSQLLockStore(db *sql.DB, table string) *SQLLockStore {
+	return &SQLLockStore{
+		db:          db,
+		table:       table,
+		Placeholder: QuestionPlaceholder,
+	}
+}
+
+// Migrate create the lock table if not exists
+func (s *SQLLockStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+s.table+" ("+
+		"lock_key VARCHAR(255) NOT NULL PRIMARY KEY, "+
+		"owner VARCHAR(255) NOT NULL, "+
+		"expires_at BIGINT NOT NULL)")
+	return err
+}
+
+// Obtain try to obtain the lock of key by inserting a row, the expired rows of all keys will be deleted first,
+// so the table doesn't grow with the keys of every run.
+func (s *SQLLockStore) Obtain(ctx context.Context, key string, expiry time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, s.bind("DELETE FROM "+s.table+" WHERE expires_at <= ?"), now.UnixNano())
+	if err != nil {
+		return false, err
+	}
+	_, err = s.db.ExecContext(ctx, s.bind("INSERT INTO "+s.table+" (lock_key, owner, expires_at) VALUES (?, ?, ?)"),
+		key, lockOwner(), now.Add(expiry).UnixNano())
+	if err == nil {
+		return true, nil
+	}
+	// the insert fails with the unique constraint if the lock is held by other server
+	var count int
+	if e := s.db.QueryRowContext(ctx, s.bind("SELECT COUNT(*) FROM "+s.table+" WHERE lock_key = ?"), key).Scan(&count); e != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s *SQLLockStore) bind(query string) string {
+	if s.Placeholder == nil {
+		return query
+	}
+	return bindSQL(query, s.Placeholder)
+}
@@ -0,0 +1,105 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openSQLite open the sqlite database in the temp directory, the test is skipped if the driver can't work,
+// e.g. it's built without cgo.
+func openSQLite(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	if err = db.Ping(); err != nil {
+		t.Skip("sqlite is not available: ", err)
+	}
+	return db
+}
+
+func TestBindSQL(t *testing.T) {
+	query := "INSERT INTO locks (lock_key, owner, expires_at) VALUES (?, ?, ?)"
+	assert.Equal(t, query, bindSQL(query, QuestionPlaceholder))
+	assert.Equal(t, "INSERT INTO locks (lock_key, owner, expires_at) VALUES ($1, $2, $3)",
+		bindSQL(query, DollarPlaceholder))
+	assert.Equal(t, query, (&SQLLockStore{}).bind(query))
+}
+
+func TestSQLLockStore_Obtain(t *testing.T) {
+	db := openSQLite(t, "lock.db")
+	ctx := context.Background()
+	store := NewSQLLockStore(db, "schedule_locks")
+	require.NoError(t, store.Migrate(ctx))
+	assert.NoError(t, store.Migrate(ctx))
+	ok, err := store.Obtain(ctx, "schedule:task:202210051530", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = NewSQLLockStore(db, "schedule_locks").Obtain(ctx, "schedule:task:202210051530", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = store.Obtain(ctx, "schedule:task:202210051531", -time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = store.Obtain(ctx, "schedule:task:202210051531", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSQLLockStore_purge(t *testing.T) {
+	db := openSQLite(t, "lock.db")
+	ctx := context.Background()
+	store := NewSQLLockStore(db, "schedule_locks")
+	require.NoError(t, store.Migrate(ctx))
+	for _, key := range []string{"schedule:a:202210051530", "schedule:b:202210051530"} {
+		ok, err := store.Obtain(ctx, key, -time.Second)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, err := store.Obtain(ctx, "schedule:a:202210051531", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// the expired rows of other keys are removed
+	var keys []string
+	rows, err := db.QueryContext(ctx, "SELECT lock_key FROM schedule_locks")
+	assert.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		assert.NoError(t, rows.Scan(&key))
+		keys = append(keys, key)
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, []string{"schedule:a:202210051531"}, keys)
+}
+
+func TestSQLLockStore_error(t *testing.T) {
+	db := openSQLite(t, "lock.db")
+	ctx := context.Background()
+	ok, err := NewSQLLockStore(db, "missing").Obtain(ctx, "key", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	// the insert fails without the unique constraint of lock key
+	_, err = db.ExecContext(ctx, "CREATE TABLE broken (lock_key VARCHAR(255), expires_at BIGINT)")
+	assert.NoError(t, err)
+	ok, err = NewSQLLockStore(db, "broken").Obtain(ctx, "key", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE readonly (lock_key VARCHAR(255) NOT NULL, owner VARCHAR(255) NOT NULL, "+
+		"expires_at BIGINT NOT NULL, CHECK (expires_at < 0))")
+	assert.NoError(t, err)
+	ok, err = NewSQLLockStore(db, "readonly").Obtain(ctx, "key", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,77 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryLockStore the lock store shared by the schedulers of the fake servers
+type memoryLockStore struct {
+	mu   sync.Mutex
+	keys map[string]bool
+	err  error
+}
+
+func (m *memoryLockStore) Obtain(ctx context.Context, key string, expiry time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.keys[key] {
+		return false, nil
+	}
+	m.keys[key] = true
+	return true, nil
+}
+
+func TestOneServerKey(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	at := time.Date(2022, 10, 5, 23, 30, 45, 0, loc)
+	assert.Equal(t, "schedule:report:202210051530", oneServerKey("report", at, false))
+	assert.Equal(t, "schedule:report:20221005153045", oneServerKey("report", at, true))
+	assert.NotEmpty(t, lockOwner())
+}
+
+func TestEvent_OnOneServer(t *testing.T) {
+	store := &memoryLockStore{keys: make(map[string]bool)}
+	var mu sync.Mutex
+	count := 0
+	for i := 0; i < 3; i++ {
+		s := NewScheduler(context.Background(), time.UTC)
+		s.current, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+		s.Event = s.newEvent()
+		s.SetLockStore(nil).SetLockStore(store)
+		s.Task(func(ctx context.Context) {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}).Name("report").EveryMinute().OnOneServer()
+		s.Start()
+		assert.Equal(t, []string{"OnOneServer()"}, s.Tasks()[0].Constraints)
+	}
+	assert.Equal(t, 1, count)
+	assert.True(t, store.keys["schedule:report:202210051530"])
+}
+
+func TestEvent_OnOneServer_error(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	var mark bool
+	s.EveryMinute().OnOneServer().CallFunc(func(ctx context.Context) {
+		mark = true
+	})
+	s.Start()
+	assert.False(t, mark)
+
+	s.SetLockStore(&memoryLockStore{err: errors.New("connection refused")})
+	s.EveryMinute().OnOneServer().CallFunc(func(ctx context.Context) {
+		mark = true
+	})
+	s.Start()
+	assert.False(t, mark)
+}
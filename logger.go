@@ -0,0 +1,111 @@
+// Package schedule
+// file contains the structured leveled logger and the shim of legacy logger.
+package schedule
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Level the severity of log, the values are same as log/slog
+type Level int
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+// String return the name of level
+func (l Level) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// the keys of log fields
+const (
+	FieldTask     = "task"
+	FieldRunID    = "run_id"
+	FieldDuration = "duration"
+	FieldError    = "error"
+)
+
+// Field the key/value pair of structured log
+type Field struct {
+	Key   string
+	Value any
+}
+
+// StructuredLogger the leveled logger with key/value fields
+type StructuredLogger interface {
+	Log(ctx context.Context, level Level, msg string, fields ...Field)
+}
+
+// Log print the log with the level and fields like `WARN Task exceeded its timeout task=backup run_id=4f2a9c1e8b7d6a50`,
+// the log below the minimum level is dropped.
+func (d *DefaultLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if level < d.Level {
+		return
+	}
+	log.Println(level.String() + " " + msg + formatFields(fields))
+}
+
+// legacyLogger the shim to use the legacy Logger as StructuredLogger
+type legacyLogger struct {
+	l Logger
+}
+
+func (s *legacyLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	if level < LevelError {
+		s.l.Debug(msg + formatFields(fields))
+		return
+	}
+	var e any
+	rest := make([]Field, 0, len(fields))
+	for _, f := range fields {
+		if e == nil && (f.Key == FieldError || f.Key == "panic") {
+			e = f.Value
+			continue
+		}
+		rest = append(rest, f)
+	}
+	s.l.Error(msg+formatFields(rest), e)
+}
+
+// formatFields format the fields as ` key=value` pairs, the value with spaces is quoted
+func formatFields(fields []Field) string {
+	var b strings.Builder
+	for _, f := range fields {
+		v := fmt.Sprint(f.Value)
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(" " + f.Key + "=" + v)
+	}
+	return b.String()
+}
+
+// newRunID generate a random id to identify a task run in logs
+func newRunID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (s *Scheduler) logAt(level Level, msg string, fields ...Field) {
+	s.log.Log(s.ctx, level, msg, fields...)
+}
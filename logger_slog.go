@@ -0,0 +1,32 @@
+//go:build go1.21
+
+// Package schedule
+// file contains the adapter of log/slog.
+package schedule
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger the adapter to use *slog.Logger as StructuredLogger
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger create the adapter of slog logger, the default slog logger is used if it's nil
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{l: l}
+}
+
+// Log log the message with the slog level and attributes
+func (s *SlogLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	s.l.LogAttrs(ctx, slog.Level(level), msg, attrs...)
+}
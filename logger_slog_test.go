@@ -0,0 +1,30 @@
+//go:build go1.21
+
+// Package schedule
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	l.Log(context.Background(), LevelError, "Task failed", Field{FieldTask, "backup"},
+		Field{FieldDuration, time.Second}, Field{FieldError, errors.New("broken")})
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "Task failed", record["msg"])
+	assert.Equal(t, "backup", record[FieldTask])
+	assert.Equal(t, float64(time.Second), record[FieldDuration])
+	assert.Equal(t, "broken", record[FieldError])
+	assert.NotNil(t, NewSlogLogger(nil))
+}
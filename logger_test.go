@@ -0,0 +1,149 @@
+// Package schedule
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedLog struct {
+	level  Level
+	msg    string
+	fields map[string]any
+}
+
+// recordLogger the structured logger records all logs in memory
+type recordLogger struct {
+	mu   sync.Mutex
+	logs []recordedLog
+}
+
+func (r *recordLogger) Log(ctx context.Context, level Level, msg string, fields ...Field) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[string]any)
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	r.logs = append(r.logs, recordedLog{level: level, msg: msg, fields: m})
+}
+
+func (r *recordLogger) find(msg string) *recordedLog {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.logs {
+		if r.logs[i].msg == msg {
+			return &r.logs[i]
+		}
+	}
+	return nil
+}
+
+// legacyRecordLogger the legacy logger records all messages in memory
+type legacyRecordLogger struct {
+	mu     sync.Mutex
+	errors []string
+	values []any
+	debugs []string
+}
+
+func (l *legacyRecordLogger) Error(msg string, e any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, msg)
+	l.values = append(l.values, e)
+}
+
+func (l *legacyRecordLogger) Debugf(msg string, n int32) {}
+
+func (l *legacyRecordLogger) Debug(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debugs = append(l.debugs, msg)
+}
+
+func TestLevel_String(t *testing.T) {
+	assert.Equal(t, "DEBUG", LevelDebug.String())
+	assert.Equal(t, "INFO", LevelInfo.String())
+	assert.Equal(t, "WARN", LevelWarn.String())
+	assert.Equal(t, "ERROR", LevelError.String())
+	assert.Equal(t, "ERROR", Level(12).String())
+}
+
+func TestFormatFields(t *testing.T) {
+	assert.Equal(t, "", formatFields(nil))
+	assert.Equal(t, ` task=backup duration=1.5s error="connection refused" run_id=""`, formatFields([]Field{
+		{FieldTask, "backup"},
+		{FieldDuration, 1500 * time.Millisecond},
+		{FieldError, errors.New("connection refused")},
+		{FieldRunID, ""},
+	}))
+	assert.Len(t, newRunID(), 16)
+	assert.NotEqual(t, newRunID(), newRunID())
+}
+
+func TestDefaultLogger_Log(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+	(&DefaultLogger{}).Log(context.Background(), LevelWarn, "Task exceeded its timeout", Field{FieldTask, "backup"})
+	assert.Equal(t, "WARN Task exceeded its timeout task=backup\n", buf.String())
+
+	// the debug logs are dropped by default
+	buf.Reset()
+	(&DefaultLogger{}).Log(context.Background(), LevelDebug, "Task started", Field{FieldTask, "backup"})
+	assert.Empty(t, buf.String())
+	(&DefaultLogger{Level: LevelDebug}).Log(context.Background(), LevelDebug, "Task started", Field{FieldTask, "backup"})
+	assert.Equal(t, "DEBUG Task started task=backup\n", buf.String())
+	buf.Reset()
+	(&DefaultLogger{Level: LevelError}).Log(context.Background(), LevelWarn, "Task exceeded its timeout")
+	assert.Empty(t, buf.String())
+}
+
+func TestLegacyLogger(t *testing.T) {
+	l := &legacyRecordLogger{}
+	s := NewScheduler(context.Background(), time.UTC)
+	s.SetLogger(l)
+	s.Task(func(ctx context.Context) {}).Name("ok").EveryMinute()
+	s.Task(func(ctx context.Context) {
+		panic("boom")
+	}).Name("panic").EveryMinute()
+	s.Start()
+	assert.Contains(t, l.debugs, "All tasks have been finished")
+	assert.Len(t, l.errors, 1)
+	assert.Contains(t, l.errors[0], "Recovering task from panic task=panic run_id=")
+	assert.Equal(t, []any{"boom"}, l.values)
+}
+
+func TestScheduler_SetStructuredLogger(t *testing.T) {
+	l := &recordLogger{}
+	s := NewScheduler(context.Background(), time.UTC)
+	s.SetStructuredLogger(nil).SetStructuredLogger(l)
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("failed").EveryMinute()
+	results, _ := s.Wait()
+	failed := l.find("Task failed")
+	assert.NotNil(t, failed)
+	assert.Equal(t, LevelError, failed.level)
+	assert.Equal(t, "failed", failed.fields[FieldTask])
+	assert.Equal(t, results[0].RunID, failed.fields[FieldRunID])
+	assert.Equal(t, results[0].Duration, failed.fields[FieldDuration])
+	assert.EqualError(t, failed.fields[FieldError].(error), "broken")
+	assert.NotNil(t, l.find("Task started"))
+
+	s.SetLogger(&DefaultLogger{})
+	_, ok := s.log.(*DefaultLogger)
+	assert.True(t, ok)
+}
@@ -0,0 +1,227 @@
+// Package schedule
+// file contains the metrics of task runs, and the Prometheus text exposition of them.
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// the reasons of skipped tasks
+const (
+	SkipConstraint  = "constraint"
+	SkipOverlapping = "overlapping"
+	SkipOneServer   = "one_server"
+	SkipLockError   = "lock_error"
+)
+
+// DefaultDurationBuckets the default buckets of task duration histogram in seconds
+var DefaultDurationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// Metrics the interface to track the task runs
+type Metrics interface {
+	// TaskStarted called when the task is dispatched, it may wait in the concurrency queue
+	TaskStarted(name string)
+	// TaskFinished called when the task run is finished
+	TaskFinished(r Result)
+	// TaskSkipped called when the due task is skipped by constraints or locks
+	TaskSkipped(name, reason string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) TaskStarted(name string)         {}
+func (noopMetrics) TaskFinished(r Result)           {}
+func (noopMetrics) TaskSkipped(name, reason string) {}
+
+// taskMetrics the metrics of a task
+type taskMetrics struct {
+	runs      uint64
+	failures  uint64
+	panics    uint64
+	timeouts  uint64
+	inFlight  int64
+	skipped   map[string]uint64
+	buckets   []uint64
+	sum       float64
+	queueWait float64
+}
+
+// PrometheusMetrics the in-memory metrics of task runs, which can be exposed in Prometheus text format.
+// It can be served as http.Handler, or written to the textfile of node_exporter at the end of a crontab run.
+type PrometheusMetrics struct {
+	buckets []float64
+	mu      sync.Mutex
+	tasks   map[string]*taskMetrics
+}
+
+// NewPrometheusMetrics create instance of prometheus metrics, the DefaultDurationBuckets is used if no buckets given
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &PrometheusMetrics{
+		buckets: buckets,
+		tasks:   make(map[string]*taskMetrics),
+	}
+}
+
+// TaskStarted increase the in-flight gauge of task
+func (p *PrometheusMetrics) TaskStarted(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.task(name).inFlight++
+}
+
+// TaskFinished record the outcome and duration of task run
+func (p *PrometheusMetrics) TaskFinished(r Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.task(r.Name)
+	t.inFlight--
+	t.runs++
+	switch r.Status {
+	case StatusFailed:
+		t.failures++
+	case StatusPanic:
+		t.panics++
+	case StatusTimeout:
+		t.timeouts++
+	}
+	seconds := r.Duration.Seconds()
+	for i, b := range p.buckets {
+		if seconds <= b {
+			t.buckets[i]++
+		}
+	}
+	t.sum += seconds
+	t.queueWait += r.QueueWait.Seconds()
+}
+
+// TaskSkipped count the skipped task with reason
+func (p *PrometheusMetrics) TaskSkipped(name, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.task(name).skipped[reason]++
+}
+
+func (p *PrometheusMetrics) task(name string) *taskMetrics {
+	t, ok := p.tasks[name]
+	if !ok {
+		t = &taskMetrics{
+			skipped: make(map[string]uint64),
+			buckets: make([]uint64, len(p.buckets)),
+		}
+		p.tasks[name] = t
+	}
+	return t
+}
+
+// WriteTo write the metrics in Prometheus text exposition format
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.tasks))
+	for name := range p.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	counter := func(metric, help string, value func(t *taskMetrics) uint64) {
+		b.WriteString("# HELP " + metric + " " + help + "\n# TYPE " + metric + " counter\n")
+		for _, name := range names {
+			b.WriteString(metric + "{task=" + quoteLabel(name) + "} " + strconv.FormatUint(value(p.tasks[name]), 10) + "\n")
+		}
+	}
+	counter("schedule_task_runs_total", "The total number of task runs.", func(t *taskMetrics) uint64 { return t.runs })
+	counter("schedule_task_failures_total", "The total number of failed task runs.", func(t *taskMetrics) uint64 { return t.failures })
+	counter("schedule_task_panics_total", "The total number of panicked task runs.", func(t *taskMetrics) uint64 { return t.panics })
+	counter("schedule_task_timeouts_total", "The total number of timed out task runs.", func(t *taskMetrics) uint64 { return t.timeouts })
+
+	b.WriteString("# HELP schedule_task_skipped_total The total number of due tasks skipped by constraints or locks.\n")
+	b.WriteString("# TYPE schedule_task_skipped_total counter\n")
+	for _, name := range names {
+		t := p.tasks[name]
+		reasons := make([]string, 0, len(t.skipped))
+		for reason := range t.skipped {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			fmt.Fprintf(&b, "schedule_task_skipped_total{task=%s,reason=%s} %d\n", quoteLabel(name), quoteLabel(reason), t.skipped[reason])
+		}
+	}
+
+	b.WriteString("# HELP schedule_task_in_flight The number of running and queued tasks.\n")
+	b.WriteString("# TYPE schedule_task_in_flight gauge\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "schedule_task_in_flight{task=%s} %d\n", quoteLabel(name), p.tasks[name].inFlight)
+	}
+
+	b.WriteString("# HELP schedule_task_duration_seconds The duration of task runs.\n")
+	b.WriteString("# TYPE schedule_task_duration_seconds histogram\n")
+	for _, name := range names {
+		t := p.tasks[name]
+		for i, bound := range p.buckets {
+			fmt.Fprintf(&b, "schedule_task_duration_seconds_bucket{task=%s,le=%s} %d\n", quoteLabel(name),
+				quoteLabel(formatFloat(bound)), t.buckets[i])
+		}
+		fmt.Fprintf(&b, "schedule_task_duration_seconds_bucket{task=%s,le=\"+Inf\"} %d\n", quoteLabel(name), t.runs)
+		fmt.Fprintf(&b, "schedule_task_duration_seconds_sum{task=%s} %s\n", quoteLabel(name), formatFloat(t.sum))
+		fmt.Fprintf(&b, "schedule_task_duration_seconds_count{task=%s} %d\n", quoteLabel(name), t.runs)
+	}
+
+	b.WriteString("# HELP schedule_task_queue_wait_seconds_total The total duration of tasks waited in the concurrency queue.\n")
+	b.WriteString("# TYPE schedule_task_queue_wait_seconds_total counter\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "schedule_task_queue_wait_seconds_total{task=%s} %s\n", quoteLabel(name), formatFloat(p.tasks[name].queueWait))
+	}
+	p.mu.Unlock()
+	return b.WriteTo(w)
+}
+
+// ServeHTTP serve the metrics for Prometheus to scrape
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTextfile write the metrics to the textfile of node_exporter, the file is replaced atomically
+func (p *PrometheusMetrics) WriteTextfile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = p.WriteTo(tmp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// quoteLabel quote the label value with the escaping of Prometheus text format
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(v) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
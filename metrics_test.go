@@ -0,0 +1,132 @@
+// Package schedule
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	m := NewPrometheusMetrics(0.5, 0.1)
+	m.TaskStarted("backup")
+	m.TaskFinished(Result{Name: "backup", Status: StatusSuccess, Duration: 200 * time.Millisecond, QueueWait: time.Second})
+	m.TaskStarted("backup")
+	m.TaskFinished(Result{Name: "backup", Status: StatusFailed, Duration: 50 * time.Millisecond})
+	m.TaskStarted("report \"daily\"")
+	m.TaskFinished(Result{Name: "report \"daily\"", Status: StatusPanic})
+	m.TaskStarted("report \"daily\"")
+	m.TaskFinished(Result{Name: "report \"daily\"", Status: StatusTimeout, Duration: time.Second})
+	m.TaskStarted("report \"daily\"")
+	m.TaskSkipped("backup", SkipOverlapping)
+	m.TaskSkipped("backup", SkipConstraint)
+	var b bytes.Buffer
+	_, err := m.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP schedule_task_runs_total The total number of task runs.
+# TYPE schedule_task_runs_total counter
+schedule_task_runs_total{task="backup"} 2
+schedule_task_runs_total{task="report \"daily\""} 2
+# HELP schedule_task_failures_total The total number of failed task runs.
+# TYPE schedule_task_failures_total counter
+schedule_task_failures_total{task="backup"} 1
+schedule_task_failures_total{task="report \"daily\""} 0
+# HELP schedule_task_panics_total The total number of panicked task runs.
+# TYPE schedule_task_panics_total counter
+schedule_task_panics_total{task="backup"} 0
+schedule_task_panics_total{task="report \"daily\""} 1
+# HELP schedule_task_timeouts_total The total number of timed out task runs.
+# TYPE schedule_task_timeouts_total counter
+schedule_task_timeouts_total{task="backup"} 0
+schedule_task_timeouts_total{task="report \"daily\""} 1
+# HELP schedule_task_skipped_total The total number of due tasks skipped by constraints or locks.
+# TYPE schedule_task_skipped_total counter
+schedule_task_skipped_total{task="backup",reason="constraint"} 1
+schedule_task_skipped_total{task="backup",reason="overlapping"} 1
+# HELP schedule_task_in_flight The number of running and queued tasks.
+# TYPE schedule_task_in_flight gauge
+schedule_task_in_flight{task="backup"} 0
+schedule_task_in_flight{task="report \"daily\""} 1
+# HELP schedule_task_duration_seconds The duration of task runs.
+# TYPE schedule_task_duration_seconds histogram
+schedule_task_duration_seconds_bucket{task="backup",le="0.1"} 1
+schedule_task_duration_seconds_bucket{task="backup",le="0.5"} 2
+schedule_task_duration_seconds_bucket{task="backup",le="+Inf"} 2
+schedule_task_duration_seconds_sum{task="backup"} 0.25
+schedule_task_duration_seconds_count{task="backup"} 2
+schedule_task_duration_seconds_bucket{task="report \"daily\"",le="0.1"} 1
+schedule_task_duration_seconds_bucket{task="report \"daily\"",le="0.5"} 1
+schedule_task_duration_seconds_bucket{task="report \"daily\"",le="+Inf"} 2
+schedule_task_duration_seconds_sum{task="report \"daily\""} 1
+schedule_task_duration_seconds_count{task="report \"daily\""} 2
+# HELP schedule_task_queue_wait_seconds_total The total duration of tasks waited in the concurrency queue.
+# TYPE schedule_task_queue_wait_seconds_total counter
+schedule_task_queue_wait_seconds_total{task="backup"} 1
+schedule_task_queue_wait_seconds_total{task="report \"daily\""} 0
+`, b.String())
+	assert.Equal(t, `"a\\b\nc"`, quoteLabel("a\\b\nc"))
+	assert.Equal(t, DefaultDurationBuckets, NewPrometheusMetrics().buckets)
+}
+
+func TestPrometheusMetrics_ServeHTTP(t *testing.T) {
+	m := NewPrometheusMetrics()
+	m.TaskStarted("backup")
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `schedule_task_in_flight{task="backup"} 1`)
+}
+
+func TestPrometheusMetrics_WriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedule.prom")
+	m := NewPrometheusMetrics()
+	m.TaskStarted("backup")
+	assert.NoError(t, m.WriteTextfile(path))
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `schedule_task_in_flight{task="backup"} 1`)
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1)
+	assert.Error(t, m.WriteTextfile(filepath.Join(dir, "missing", "schedule.prom")))
+	assert.Error(t, m.WriteTextfile(dir))
+}
+
+func TestScheduler_SetMetrics(t *testing.T) {
+	m := NewPrometheusMetrics()
+	s := New(context.Background(), WithLocation(time.UTC), WithMetrics(m))
+	s.SetMetrics(nil)
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("failed").EveryMinute()
+	s.Task(func(ctx context.Context) {}).Name("weekend").EveryMinute().When(func(ctx context.Context) bool {
+		return false
+	})
+	s.Task(func(ctx context.Context) {}).Name("remote").EveryMinute().OnOneServer()
+	s.EveryMinute().Name("direct").When(func(ctx context.Context) bool {
+		return false
+	}).CallFunc(func(ctx context.Context) {})
+	s.Start()
+	s.tick(s.clock.Now().Truncate(time.Minute).Add(time.Minute))
+	s.Start()
+	var b bytes.Buffer
+	_, _ = m.WriteTo(&b)
+	text := b.String()
+	for _, line := range []string{
+		`schedule_task_runs_total{task="failed"} 2`,
+		`schedule_task_failures_total{task="failed"} 2`,
+		`schedule_task_skipped_total{task="weekend",reason="constraint"} 2`,
+		`schedule_task_skipped_total{task="direct",reason="constraint"} 2`,
+		`schedule_task_skipped_total{task="remote",reason="lock_error"} 2`,
+		`schedule_task_in_flight{task="failed"} 0`,
+	} {
+		assert.True(t, strings.Contains(text, line), line)
+	}
+}
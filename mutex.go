@@ -0,0 +1,157 @@
+// Package schedule
+// file contains the mutex to prevent tasks from overlapping.
+package schedule
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMutexExpiry the default expiry of the overlapping mutex, same as laravel's 1440 minutes
+const DefaultMutexExpiry = 24 * time.Hour
+
+// Mutex the mutex interface to prevent a task from overlapping
+type Mutex interface {
+	// Lock try to acquire the lock of the task, it should return false if the lock is held and not expired
+	Lock(name string, expiry time.Duration) (bool, error)
+	// Unlock release the lock of the task
+	Unlock(name string) error
+}
+
+// MemoryMutex the in-process mutex, it's only effective inside the same process
+type MemoryMutex struct {
+	mu    sync.Mutex
+	locks map[string]time.Time
+}
+
+// NewMemoryMutex create instance of in-process mutex
+func NewMemoryMutex() *MemoryMutex {
+	return &MemoryMutex{locks: make(map[string]time.Time)}
+}
+
+// Lock try to acquire the lock of the task
+func (m *MemoryMutex) Lock(name string, expiry time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if expiresAt, ok := m.locks[name]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	m.locks[name] = now.Add(expiry)
+	return true, nil
+}
+
+// Unlock release the lock of the task
+func (m *MemoryMutex) Unlock(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.locks, name)
+	return nil
+}
+
+// FileMutex the mutex base on lock files in a directory,
+// so the processes launched by crontab on the same host also respect it.
+// The lock file contains its expiry time and owner token, a stale lock left by a crashed process will be taken over
+// after it expires. The lock file is locked by flock while it's checked and written, so only one process takes it over,
+// and it's emptied instead of removed on unlock. The platforms without flock only have the best effort.
+type FileMutex struct {
+	dir    string
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewFileMutex create instance of file mutex, the lock files will be created in dir
+func NewFileMutex(dir string) *FileMutex {
+	return &FileMutex{dir: dir, tokens: make(map[string]string)}
+}
+
+// Lock try to acquire the lock of the task
+func (m *FileMutex) Lock(name string, expiry time.Duration) (bool, error) {
+	f, err := os.OpenFile(m.path(name), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err = lockFile(f); err != nil {
+		return false, err
+	}
+	defer unlockFile(f)
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return false, err
+	}
+	if expiresAt, _, ok := parseLockFile(content); ok && time.Now().UnixNano() < expiresAt {
+		return false, nil
+	}
+	token := lockOwner() + ":" + newRunID()
+	if err = writeLockFile(f, strconv.FormatInt(time.Now().Add(expiry).UnixNano(), 10)+" "+token); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	m.tokens[name] = token
+	m.mu.Unlock()
+	return true, nil
+}
+
+// Unlock release the lock of the task, the lock taken over by others after it expired is kept
+func (m *FileMutex) Unlock(name string) error {
+	m.mu.Lock()
+	token := m.tokens[name]
+	delete(m.tokens, name)
+	m.mu.Unlock()
+	f, err := os.OpenFile(m.path(name), os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = lockFile(f); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if _, owner, ok := parseLockFile(content); !ok || owner != token {
+		return nil
+	}
+	return writeLockFile(f, "")
+}
+
+// parseLockFile parse the expiry time and owner token of lock file, ok is false if it's empty or broken
+func parseLockFile(content []byte) (expiresAt int64, owner string, ok bool) {
+	fields := strings.Fields(string(content))
+	if len(fields) != 2 {
+		return 0, "", false
+	}
+	expiresAt, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return expiresAt, fields[1], true
+}
+
+// writeLockFile replace the content of lock file
+func writeLockFile(f *os.File, content string) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(content), 0)
+	return err
+}
+
+func (m *FileMutex) path(name string) string {
+	sum := sha1.Sum([]byte(name))
+	return filepath.Join(m.dir, "schedule-"+hex.EncodeToString(sum[:])+".lock")
+}
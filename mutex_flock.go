@@ -0,0 +1,20 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+// Package schedule
+// file contains the file lock of FileMutex on the platforms with flock.
+package schedule
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile lock the file exclusively, it blocks until the lock is acquired
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+// unlockFile unlock the file
+func unlockFile(f *os.File) {
+	_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+// Package schedule
+// file contains the file lock of FileMutex on the platforms without flock.
+package schedule
+
+import "os"
+
+// lockFile do nothing without flock, the check and takeover of lock file are not serialized across processes
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile do nothing without flock
+func unlockFile(f *os.File) {}
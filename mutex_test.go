@@ -0,0 +1,154 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryMutex(t *testing.T) {
+	m := NewMemoryMutex()
+	ok, err := m.Lock("task", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = m.Lock("task", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, _ = m.Lock("other", time.Minute)
+	assert.True(t, ok)
+	assert.NoError(t, m.Unlock("task"))
+	ok, _ = m.Lock("task", -time.Second)
+	assert.True(t, ok)
+	ok, _ = m.Lock("task", time.Minute)
+	assert.True(t, ok)
+}
+
+func TestFileMutex(t *testing.T) {
+	dir := t.TempDir()
+	m1 := NewFileMutex(dir)
+	m2 := NewFileMutex(dir)
+	ok, err := m1.Lock("task", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = m2.Lock("task", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, _ = m2.Lock("other", time.Minute)
+	assert.True(t, ok)
+	assert.NoError(t, m1.Unlock("task"))
+	assert.NoError(t, m1.Unlock("task"))
+	ok, _ = m2.Lock("task", -time.Second)
+	assert.True(t, ok)
+	ok, _ = m1.Lock("task", time.Minute)
+	assert.True(t, ok)
+
+	// a broken lock file is treated as expired
+	assert.NoError(t, os.WriteFile(m1.path("broken"), []byte("broken"), 0644))
+	ok, _ = m1.Lock("broken", time.Minute)
+	assert.True(t, ok)
+	content, err := os.ReadFile(m1.path("broken"))
+	assert.NoError(t, err)
+	expiresAt, owner, ok := parseLockFile(content)
+	assert.True(t, ok)
+	assert.Greater(t, expiresAt, time.Now().UnixNano())
+	assert.Equal(t, m1.tokens["broken"], owner)
+	assert.True(t, strings.HasPrefix(owner, lockOwner()+":"))
+}
+
+func TestFileMutex_owner(t *testing.T) {
+	dir := t.TempDir()
+	m1 := NewFileMutex(dir)
+	m2 := NewFileMutex(dir)
+	ok, _ := m1.Lock("task", -time.Second)
+	assert.True(t, ok)
+	ok, _ = m2.Lock("task", time.Minute)
+	assert.True(t, ok)
+
+	// the lock taken over by others is not released
+	assert.NoError(t, m1.Unlock("task"))
+	ok, _ = NewFileMutex(dir).Lock("task", time.Minute)
+	assert.False(t, ok)
+	assert.NoError(t, m2.Unlock("task"))
+	content, err := os.ReadFile(m2.path("task"))
+	assert.NoError(t, err)
+	assert.Empty(t, content)
+	ok, _ = NewFileMutex(dir).Lock("task", time.Minute)
+	assert.True(t, ok)
+}
+
+func TestFileMutex_takeover(t *testing.T) {
+	dir := t.TempDir()
+	ok, _ := NewFileMutex(dir).Lock("task", -time.Second)
+	assert.True(t, ok)
+	var wg sync.WaitGroup
+	var locked int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := NewFileMutex(dir).Lock("task", time.Minute); err == nil && ok {
+				atomic.AddInt32(&locked, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), locked)
+}
+
+func TestFileMutex_error(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	m := NewFileMutex(dir)
+	ok, err := m.Lock("task", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, m.Unlock("task"))
+	assert.NoError(t, os.MkdirAll(m.path("dir"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(m.path("dir"), "file"), nil, 0644))
+	ok, err = m.Lock("dir", time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.Error(t, m.Unlock("dir"))
+}
+
+func TestEvent_WithoutOverlapping(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.current, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	s.Event = s.newEvent()
+	s.SetMutex(nil).SetMutex(NewFileMutex(t.TempDir()))
+	release := make(chan bool)
+	started := make(chan bool, 2)
+	s.Task(func(ctx context.Context) {
+		started <- true
+		<-release
+	}).Name("long").EveryMinute().WithoutOverlapping(0)
+	s.evaluatePending()
+	<-started
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:00")
+	s.tick(now)
+	assert.Len(t, started, 0)
+	assert.Equal(t, []string{"WithoutOverlapping(24h0m0s)"}, s.Tasks()[0].Constraints)
+	close(release)
+	s.Start()
+	now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:32:00")
+	s.tick(now)
+	<-started
+	s.Start()
+}
+
+func TestEvent_WithoutOverlapping_error(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.SetMutex(NewFileMutex(filepath.Join(t.TempDir(), "missing")))
+	var mark bool
+	s.EveryMinute().WithoutOverlapping(time.Minute).CallFunc(func(ctx context.Context) {
+		mark = true
+	})
+	s.Start()
+	assert.False(t, mark)
+}
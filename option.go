@@ -0,0 +1,91 @@
+// Package schedule
+// file contains the options of scheduler constructor.
+package schedule
+
+import (
+	"time"
+)
+
+// Option the option of scheduler, it's applied by `New`
+type Option func(s *Scheduler)
+
+// WithLocation set the default time.location of tasks
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		if loc != nil {
+			s.location = loc
+		}
+	}
+}
+
+// WithLogger set the legacy logger, see `SetLogger`
+func WithLogger(l Logger) Option {
+	return func(s *Scheduler) {
+		s.SetLogger(l)
+	}
+}
+
+// WithStructuredLogger set the structured logger, see `SetStructuredLogger`
+func WithStructuredLogger(l StructuredLogger) Option {
+	return func(s *Scheduler) {
+		s.SetStructuredLogger(l)
+	}
+}
+
+// WithClock set the clock, see `SetClock`
+func WithClock(c Clock) Option {
+	return func(s *Scheduler) {
+		if c != nil {
+			s.clock = c
+		}
+	}
+}
+
+// WithMutex set the mutex for the tasks without overlapping, see `SetMutex`
+func WithMutex(m Mutex) Option {
+	return func(s *Scheduler) {
+		s.SetMutex(m)
+	}
+}
+
+// WithLockStore set the lock store for the tasks run on one server, see `SetLockStore`
+func WithLockStore(l LockStore) Option {
+	return func(s *Scheduler) {
+		s.SetLockStore(l)
+	}
+}
+
+// WithLastRunStore set the last run store for the tasks with `CatchUp`, see `SetLastRunStore`
+func WithLastRunStore(l LastRunStore) Option {
+	return func(s *Scheduler) {
+		s.SetLastRunStore(l)
+	}
+}
+
+// WithHistoryStore set the store to record the history of task runs, see `SetHistoryStore`
+func WithHistoryStore(h HistoryStore) Option {
+	return func(s *Scheduler) {
+		s.SetHistoryStore(h)
+	}
+}
+
+// WithGracePeriod set the max duration to wait the running tasks when the scheduler stops, see `SetGracePeriod`
+func WithGracePeriod(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.SetGracePeriod(d)
+	}
+}
+
+// WithMaxConcurrency set the max number of tasks run at the same time, see `SetMaxConcurrency`
+func WithMaxConcurrency(n int) Option {
+	return func(s *Scheduler) {
+		s.SetMaxConcurrency(n)
+	}
+}
+
+// WithMetrics set the metrics to track the task runs, see `SetMetrics`
+func WithMetrics(m Metrics) Option {
+	return func(s *Scheduler) {
+		s.SetMetrics(m)
+	}
+}
@@ -0,0 +1,42 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	s := New(context.Background())
+	assert.Equal(t, time.Local, s.location)
+	assert.Equal(t, time.Local, s.now.Location())
+	assert.IsType(t, SystemClock{}, s.clock)
+
+	prc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Date(2022, 10, 5, 1, 0, 0, 0, time.UTC)
+	logger := &recordLogger{}
+	mutex := NewMemoryMutex()
+	store := &memoryLockStore{keys: make(map[string]bool)}
+	s = New(context.Background(),
+		WithClock(NewFakeClock(now)),
+		WithClock(nil),
+		WithLocation(prc),
+		WithLocation(nil),
+		WithStructuredLogger(logger),
+		WithMutex(mutex),
+		WithLockStore(store),
+		WithGracePeriod(time.Second),
+	)
+	assert.Equal(t, prc, s.location)
+	assert.True(t, now.Equal(s.now))
+	assert.Equal(t, prc, s.now.Location())
+	assert.Same(t, logger, s.log)
+	assert.Same(t, mutex, s.mutex)
+	assert.Same(t, store, s.locks)
+	assert.Equal(t, time.Second, s.grace)
+
+	s = New(context.Background(), WithLogger(&legacyRecordLogger{}))
+	assert.IsType(t, &legacyLogger{}, s.log)
+}
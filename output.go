@@ -0,0 +1,160 @@
+// Package schedule
+// file contains the output routing of tasks.
+package schedule
+
+import (
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type outputKey struct{}
+
+// output the output target of task, it's a file path or a writer
+type output struct {
+	path   string
+	append bool
+	writer io.Writer
+}
+
+// open open the output target for a task run, the returned close function should be called after the run
+func (o output) open() (io.Writer, func() error, error) {
+	if o.writer != nil {
+		return &syncWriter{w: o.writer}, func() error { return nil }, nil
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if o.append {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(o.path, flag, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &syncWriter{w: f}, f.Close, nil
+}
+
+func (o output) isSet() bool {
+	return o.writer != nil || o.path != ""
+}
+
+// withOutput return a context carries the output writer of task
+func withOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// OutputWriter return the output writer of task from the task context, it's io.Discard if no output is set.
+// s.Task(fn).SendOutputTo(path), then fmt.Fprintln(OutputWriter(ctx), "done") in fn writes to the file.
+func OutputWriter(ctx context.Context) io.Writer {
+	if w, ok := outputFrom(ctx); ok {
+		return w
+	}
+	return io.Discard
+}
+
+func outputFrom(ctx context.Context) (io.Writer, bool) {
+	w, ok := ctx.Value(outputKey{}).(io.Writer)
+	return w, ok
+}
+
+// syncWriter the writer can be shared by the stdout and stderr of command
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// RotatingFile the file writer rotates by size, the file is renamed to `path.1` when it exceeds the max size,
+// and the old backups are renamed to `path.2` ... `path.N`, at most N backups are kept.
+type RotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+}
+
+// NewRotatingFile create instance of rotating file, the file is never rotated if maxSize is not positive
+func NewRotatingFile(path string, maxSize int64, backups int) *RotatingFile {
+	return &RotatingFile{
+		path:    path,
+		maxSize: maxSize,
+		backups: backups,
+	}
+}
+
+// Write append the data to file, rotate the file first if it will exceed the max size
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close close the file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	if r.backups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		err := os.Rename(r.path+"."+strconv.Itoa(i), r.path+"."+strconv.Itoa(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return r.open()
+}
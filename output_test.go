@@ -0,0 +1,92 @@
+// Package schedule
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutputWriter(t *testing.T) {
+	assert.Equal(t, io.Discard, OutputWriter(context.Background()))
+	var buf bytes.Buffer
+	_, _ = fmt.Fprint(OutputWriter(withOutput(context.Background(), &buf)), "done")
+	assert.Equal(t, "done", buf.String())
+}
+
+func TestEvent_SendOutputTo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task.log")
+	s := NewScheduler(context.Background(), time.UTC)
+	e := s.Task(func(ctx context.Context) {
+		_, _ = fmt.Fprintln(OutputWriter(ctx), "run")
+	}).SendOutputTo(path)
+	for i := 0; i < 2; i++ {
+		s.dispatch(e, s.now)
+		s.Start()
+	}
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "run\n", string(content))
+
+	e.AppendOutputTo(path)
+	s.dispatch(e, s.now)
+	s.Start()
+	content, _ = os.ReadFile(path)
+	assert.Equal(t, "run\nrun\n", string(content))
+
+	// the task still runs if the output can't be opened
+	var mark bool
+	s.Task(func(ctx context.Context) {
+		mark = true
+		assert.Equal(t, io.Discard, OutputWriter(ctx))
+	}).EveryMinute().SendOutputTo(filepath.Join(t.TempDir(), "missing", "task.log"))
+	s.Start()
+	assert.True(t, mark)
+}
+
+func TestEvent_OutputTo(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewScheduler(context.Background(), time.UTC)
+	s.EveryMinute().OutputTo(&buf).CallFunc(func(ctx context.Context) {
+		_, _ = io.WriteString(OutputWriter(ctx), "hello")
+	})
+	s.Start()
+	assert.Equal(t, "hello", buf.String())
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task.log")
+	r := NewRotatingFile(path, 10, 2)
+	assert.NoError(t, r.Close())
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := io.WriteString(r, line)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.Close())
+	read := func(name string) string {
+		content, _ := os.ReadFile(name)
+		return string(content)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	// the size of existing file is counted
+	r = NewRotatingFile(path, 10, 0)
+	_, _ = io.WriteString(r, "fifth\n")
+	_, _ = io.WriteString(r, "sixth\n")
+	assert.NoError(t, r.Close())
+	assert.Equal(t, "sixth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+
+	r = NewRotatingFile(filepath.Join(t.TempDir(), "missing", "task.log"), 10, 0)
+	_, err := io.WriteString(r, "lost")
+	assert.Error(t, err)
+}
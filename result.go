@@ -0,0 +1,108 @@
+// Package schedule
+// file contains the results of the task runs.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result the outcome of a task run
+type Result struct {
+	Name   string
+	RunID  string
+	Status Status
+	// Scheduled the time the task is scheduled to run
+	Scheduled time.Time
+	Start     time.Time
+	End       time.Time
+	Duration  time.Duration
+	// QueueWait the duration waited in the concurrency queue before start
+	QueueWait time.Duration
+	Err       error
+	Panic     any
+}
+
+// Failed check the task run is failed, panicked, timed out or still running
+func (r Result) Failed() bool {
+	return r.Status != StatusSuccess
+}
+
+// String describe the outcome of the task run
+func (r Result) String() string {
+	switch {
+	case r.Panic != nil:
+		return r.Name + ": panic: " + fmt.Sprint(r.Panic)
+	case r.Status == StatusRunning:
+		return r.Name + ": still running"
+	case r.Status == StatusTimeout && r.Err == nil:
+		return r.Name + ": timeout after " + r.Duration.String()
+	case r.Err != nil:
+		return r.Name + ": " + string(r.Status) + ": " + r.Err.Error()
+	default:
+		return r.Name + ": " + string(r.Status)
+	}
+}
+
+// RunError the aggregated error of the failed task runs
+type RunError struct {
+	Results []Result
+}
+
+// Error describe all failed task runs
+func (e *RunError) Error() string {
+	msgs := make([]string, 0, len(e.Results))
+	for _, r := range e.Results {
+		msgs = append(msgs, r.String())
+	}
+	return "schedule: " + strconv.Itoa(len(e.Results)) + " task(s) failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap return the errors of the failed task runs
+func (e *RunError) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
+
+// Is report whether any error of the failed task runs matches target,
+// so `errors.Is` works before Go 1.20 which follows the `Unwrap() []error` method.
+func (e *RunError) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As find the first error of the failed task runs that matches target like `errors.As`
+func (e *RunError) As(target any) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// runError return the RunError of the failed results, or nil if all tasks succeed
+func runError(results []Result) error {
+	var failed []Result
+	for _, r := range results {
+		if r.Failed() {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &RunError{Results: failed}
+}
@@ -0,0 +1,111 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResult_String(t *testing.T) {
+	assert.Equal(t, "a: success", Result{Name: "a", Status: StatusSuccess}.String())
+	assert.Equal(t, "a: failed: broken", Result{Name: "a", Status: StatusFailed, Err: errors.New("broken")}.String())
+	assert.Equal(t, "a: panic: boom", Result{Name: "a", Status: StatusPanic, Panic: "boom"}.String())
+	assert.Equal(t, "a: still running", Result{Name: "a", Status: StatusRunning}.String())
+	assert.Equal(t, "a: timeout after 1s", Result{Name: "a", Status: StatusTimeout, Duration: time.Second}.String())
+	assert.Equal(t, "a: timeout: context deadline exceeded",
+		Result{Name: "a", Status: StatusTimeout, Err: context.DeadlineExceeded}.String())
+	assert.False(t, Result{Status: StatusSuccess}.Failed())
+	assert.True(t, Result{Status: StatusRunning}.Failed())
+}
+
+func TestRunError(t *testing.T) {
+	broken := errors.New("broken")
+	assert.Nil(t, runError(nil))
+	assert.Nil(t, runError([]Result{{Name: "a", Status: StatusSuccess}}))
+	err := runError([]Result{
+		{Name: "a", Status: StatusSuccess},
+		{Name: "b", Status: StatusFailed, Err: broken},
+		{Name: "c", Status: StatusPanic, Panic: "boom"},
+	})
+	var runErr *RunError
+	assert.True(t, errors.As(err, &runErr))
+	assert.Len(t, runErr.Results, 2)
+	assert.Equal(t, []error{broken}, runErr.Unwrap())
+	assert.EqualError(t, err, "schedule: 2 task(s) failed: b: failed: broken; c: panic: boom")
+
+	// the errors of task runs are matched by the Is and As methods
+	missing := &os.PathError{Op: "open", Path: "report.csv", Err: os.ErrNotExist}
+	err = runError([]Result{
+		{Name: "a", Status: StatusFailed, Err: broken},
+		{Name: "b", Status: StatusFailed, Err: fmt.Errorf("export: %w", missing)},
+	})
+	assert.True(t, runErr.Is(broken))
+	assert.False(t, runErr.Is(os.ErrNotExist))
+	assert.True(t, err.(*RunError).Is(os.ErrNotExist))
+	assert.True(t, errors.Is(err, broken))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.False(t, errors.Is(err, context.Canceled))
+	var pathErr *os.PathError
+	assert.True(t, err.(*RunError).As(&pathErr))
+	assert.Equal(t, missing, pathErr)
+	assert.True(t, errors.As(err, &pathErr))
+	var numErr *strconv.NumError
+	assert.False(t, err.(*RunError).As(&numErr))
+}
+
+type fixtureTaskE struct {
+	err error
+}
+
+func (f *fixtureTaskE) Run(ctx context.Context) error {
+	return f.err
+}
+
+func TestScheduler_Wait(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	broken := errors.New("broken")
+	s.Task(func(ctx context.Context) {}).Name("ok").EveryMinute()
+	s.JobE(&fixtureTaskE{err: broken}).Name("failed").EveryMinute()
+	s.Task(func(ctx context.Context) {
+		panic("boom")
+	}).Name("panic").EveryMinute()
+	s.EveryMinute().Name("direct").CallE(&fixtureTaskE{})
+	results, err := s.Wait()
+	assert.Len(t, results, 4)
+	assert.Error(t, err)
+	var runErr *RunError
+	assert.True(t, errors.As(err, &runErr))
+	assert.Len(t, runErr.Results, 2)
+	statuses := map[string]Status{}
+	for _, r := range results {
+		statuses[r.Name] = r.Status
+		assert.False(t, r.Start.IsZero())
+	}
+	assert.Equal(t, map[string]Status{
+		"ok":     StatusSuccess,
+		"failed": StatusFailed,
+		"panic":  StatusPanic,
+		"direct": StatusSuccess,
+	}, statuses)
+
+	results, err = s.Wait()
+	assert.Empty(t, results)
+	assert.NoError(t, err)
+
+	release := make(chan bool)
+	s.SetGracePeriod(10 * time.Millisecond)
+	s.Task(func(ctx context.Context) {
+		<-release
+	}).Name("hung").EveryMinute()
+	results, err = s.Wait()
+	assert.Equal(t, []Result{{Name: "hung", Status: StatusRunning}}, results)
+	assert.EqualError(t, err, "schedule: 1 task(s) failed: hung: still running")
+	close(release)
+	assert.Empty(t, s.SetGracePeriod(0).Start())
+}
@@ -0,0 +1,55 @@
+// Package schedule
+// file contains the backoff strategies to retry the failing tasks.
+package schedule
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff the strategy to compute the delay before the nth (start from 1) retry of a failing task
+type Backoff func(attempt int) time.Duration
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// ConstantBackoff wait the same delay before every retry
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff double the delay after every retry, start from base and capped at max.
+// The delay is not capped if max is not positive.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt; i++ {
+			if max > 0 && d >= max || d > time.Duration(1<<62) {
+				break
+			}
+			d *= 2
+		}
+		if max > 0 && d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// JitterBackoff randomize the delay of backoff between 0 and the delay, so the servers don't retry at the same time
+func JitterBackoff(b Backoff) Backoff {
+	return func(attempt int) time.Duration {
+		d := b(attempt)
+		if d <= 0 {
+			return 0
+		}
+		jitterMu.Lock()
+		defer jitterMu.Unlock()
+		return time.Duration(jitterRand.Int63n(int64(d) + 1))
+	}
+}
@@ -0,0 +1,83 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff(time.Second)
+	assert.Equal(t, time.Second, b(1))
+	assert.Equal(t, time.Second, b(10))
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(time.Second, 10*time.Second)
+	assert.Equal(t, time.Second, b(1))
+	assert.Equal(t, 2*time.Second, b(2))
+	assert.Equal(t, 8*time.Second, b(4))
+	assert.Equal(t, 10*time.Second, b(5))
+	assert.Equal(t, 10*time.Second, b(1000))
+	b = ExponentialBackoff(time.Second, 0)
+	assert.Equal(t, 16*time.Second, b(5))
+	assert.True(t, b(1000) > 0)
+}
+
+func TestJitterBackoff(t *testing.T) {
+	b := JitterBackoff(ConstantBackoff(time.Second))
+	for i := 1; i < 100; i++ {
+		d := b(i)
+		assert.True(t, d >= 0 && d <= time.Second)
+	}
+	assert.Equal(t, time.Duration(0), JitterBackoff(ConstantBackoff(0))(1))
+}
+
+func TestEvent_Retry(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	blip := errors.New("connection reset by peer")
+	attempts := 0
+	s.TaskE(func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return blip
+		}
+		return nil
+	}).Name("flaky").EveryMinute().Retry(3, ConstantBackoff(time.Millisecond))
+	failed := 0
+	s.TaskE(func(ctx context.Context) error {
+		failed++
+		return blip
+	}).Name("broken").EveryMinute().Retry(2, nil)
+	s.TaskE(func(ctx context.Context) error {
+		return blip
+	}).Name("once").EveryMinute().Retry(-1, nil)
+	s.Start()
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, failed)
+	tasks := s.Tasks()
+	assert.Equal(t, StatusSuccess, tasks[0].Status)
+	assert.Nil(t, tasks[0].Err)
+	assert.Equal(t, []string{"Retry(3)"}, tasks[0].Constraints)
+	assert.Equal(t, StatusFailed, tasks[1].Status)
+	assert.Equal(t, blip, tasks[1].Err)
+	assert.Equal(t, StatusFailed, tasks[2].Status)
+	assert.Empty(t, tasks[2].Constraints)
+}
+
+func TestEvent_Retry_context(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	attempts := 0
+	s.EveryMinute().Timeout(20*time.Millisecond).Retry(5, ConstantBackoff(time.Hour)).
+		CallFuncE(func(ctx context.Context) error {
+			attempts++
+			return errors.New("timeout")
+		})
+	s.Start()
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, StatusTimeout, s.Tasks()[0].Status)
+	assert.Equal(t, []string{"Timeout(20ms)", "Retry(5)"}, s.Tasks()[0].Constraints)
+}
@@ -0,0 +1,119 @@
+// Package schedule
+// file contains the schedule of a task, which can compute the fire times from any instant.
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// ScheduleLookahead the maximum duration to search the next fire time,
+// a schedule without any fire time in this window is treated as never fires.
+var ScheduleLookahead = 5 * 366 * 24 * time.Hour
+
+// Schedule the frequency of a task with its day and time constraints
+type Schedule struct {
+	desc     string
+	freq     frequency
+	seconds  uint64
+	limit    *Limit
+	location *time.Location
+}
+
+// Next return the next fire time after t, the `When` constraint is not evaluated.
+// The zero time will be returned if the schedule never fires in the lookahead window.
+func (sc *Schedule) Next(t time.Time) time.Time {
+	if sc.freq == nil {
+		return time.Time{}
+	}
+	t = t.In(sc.location)
+	next := t.Truncate(time.Minute)
+	end := t.Add(ScheduleLookahead)
+	for next.Before(end) {
+		if !sc.limit.allowDay(next) {
+			next = nextDay(next, sc.limit.shift)
+			continue
+		}
+		if !sc.limit.allow(next) {
+			next = sc.limit.nextTime(next)
+			continue
+		}
+		tick := sc.freq(next)
+		if !tick.onDay(next) {
+			next = nextDay(next, sc.limit.shift)
+			continue
+		}
+		if tick.match(next) {
+			if at, ok := sc.second(next, t); ok {
+				return at
+			}
+		}
+		next = next.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// second return the first fire time within the minute after t, the task runs at second 0 if it's not sub-minute
+func (sc *Schedule) second(minute, t time.Time) (time.Time, bool) {
+	seconds := sc.seconds
+	if seconds == 0 {
+		seconds = 1
+	}
+	for i := 0; i < 60; i++ {
+		at := minute.Add(time.Duration(i) * time.Second)
+		if seconds&(1<<uint(i)) > 0 && at.After(t) {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextDay return the start of next day, the day starts at the shift of spread frequency
+func nextDay(t time.Time, shift time.Duration) time.Time {
+	d := t.Add(-shift)
+	return wallClock(t, time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, d.Location()), shift)
+}
+
+// wallClock return the instant of the wall clock time at shifted by the shift of spread frequency, or the next minute
+// of t if the offset of time zone is changed after t, as the wall clock times may be repeated or skipped by the daylight
+// saving time. It's moved 2 hours earlier if the offset is changed within them, and it's always after t.
+func wallClock(t, at time.Time, shift time.Duration) time.Time {
+	_, offset := at.Zone()
+	if _, current := t.Add(-shift).Zone(); current != offset {
+		return t.Add(time.Minute)
+	}
+	if _, before := at.Add(-2 * time.Hour).Zone(); before != offset {
+		at = at.Add(-2 * time.Hour)
+	}
+	if at = at.Add(shift); at.After(t) {
+		return at
+	}
+	return t.Add(time.Minute)
+}
+
+// NextRuns return at most n fire times after t,
+// less fire times will be returned if the schedule stops firing in the lookahead window.
+func (sc *Schedule) NextRuns(t time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		t = sc.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+func (sc *Schedule) isDue(ctx context.Context, now time.Time) bool {
+	return sc.isTimeMatched(now) && sc.limit.check(ctx, now.In(sc.location))
+}
+
+// isTimeMatched check the frequency is matched at now, the constraints are not checked
+func (sc *Schedule) isTimeMatched(now time.Time) bool {
+	if sc.freq == nil {
+		return false
+	}
+	now = now.In(sc.location)
+	return sc.freq(now).match(now)
+}
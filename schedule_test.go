@@ -0,0 +1,167 @@
+// Package schedule
+package schedule
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func parseTimes(t *testing.T, layout string, values ...string) []time.Time {
+	times := make([]time.Time, 0, len(values))
+	for _, v := range values {
+		tt, err := time.Parse(layout, v)
+		assert.NoError(t, err)
+		times = append(times, tt)
+	}
+	return times
+}
+
+func TestSchedule_NextRuns(t *testing.T) {
+	layout := "2006-01-02 15:04"
+	from, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:20")
+	tests := []struct {
+		name  string
+		build func(s *Scheduler) *Event
+		want  []string
+	}{
+		{
+			name: "every five minutes",
+			build: func(s *Scheduler) *Event {
+				return s.EveryFiveMinutes()
+			},
+			want: []string{"2022-10-05 15:35", "2022-10-05 15:40", "2022-10-05 15:45"},
+		},
+		{
+			name: "hourly at between",
+			build: func(s *Scheduler) *Event {
+				return s.HourlyAt(10).Between("16:00", "17:30")
+			},
+			want: []string{"2022-10-05 16:10", "2022-10-05 17:10", "2022-10-06 16:10"},
+		},
+		{
+			name: "daily at on weekdays",
+			build: func(s *Scheduler) *Event {
+				return s.DailyAt("09:00").Weekdays()
+			},
+			want: []string{"2022-10-06 09:00", "2022-10-07 09:00", "2022-10-10 09:00"},
+		},
+		{
+			name: "weekly",
+			build: func(s *Scheduler) *Event {
+				return s.Weekly()
+			},
+			want: []string{"2022-10-09 00:00", "2022-10-16 00:00", "2022-10-23 00:00"},
+		},
+		{
+			name: "last day of month",
+			build: func(s *Scheduler) *Event {
+				return s.LastDayOfMonth("15:00")
+			},
+			want: []string{"2022-10-31 15:00", "2022-11-30 15:00", "2022-12-31 15:00"},
+		},
+		{
+			name: "cron",
+			build: func(s *Scheduler) *Event {
+				return s.Cron("0 12 29 2 *")
+			},
+			want: []string{"2024-02-29 12:00", "2028-02-29 12:00", "2032-02-29 12:00"},
+		},
+		{
+			name: "never fires",
+			build: func(s *Scheduler) *Event {
+				return s.YearlyOn(2, 30, "00:00")
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(context.Background(), time.UTC)
+			s.now = from
+			runs := tt.build(s).Schedule().NextRuns(from, 3)
+			assert.Equal(t, parseTimes(t, layout, tt.want...), runs)
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:20")
+	assert.True(t, s.Schedule().Next(now).IsZero())
+	prc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	next := s.Timezone(prc).DailyAt("09:00").When(func(ctx context.Context) bool {
+		return false
+	}).Schedule().Next(now)
+	assert.Equal(t, "2022-10-06 01:00", next.UTC().Format("2006-01-02 15:04"))
+	assert.Equal(t, prc, next.Location())
+}
+
+func TestSchedule_Next_never(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	from := time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC)
+	started := time.Now()
+	for _, e := range []*Event{
+		s.Task(nil).YearlyOn(2, 30, "00:00"),
+		s.Task(nil).Name("backup").YearlyOn(2, 30, "00:00").Spread(),
+		s.Task(nil).Cron("0 0 30 2 *"),
+		s.Task(nil).Cron("invalid"),
+		s.Task(nil).Weekly().Mondays(),
+		s.Task(nil).EveryNMinutes(0),
+		s.Task(nil).DailyAt("10:00").Between("12:00", "13:00"),
+	} {
+		assert.True(t, e.Schedule().Next(from).IsZero(), e.desc)
+	}
+	// the days and times never fire are skipped instead of scanning every minute of the lookahead
+	assert.Less(t, time.Since(started), 2*time.Second)
+}
+
+func TestSchedule_Next_dst(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	assert.NoError(t, err)
+	s := NewScheduler(context.Background(), london)
+	// 01:00 - 02:00 is skipped on 2021-03-28
+	e := s.Task(nil).EveryFiveMinutes().Between("01:30", "02:40")
+	next := e.Schedule().Next(time.Date(2021, 3, 27, 2, 40, 0, 0, london))
+	assert.Equal(t, time.Date(2021, 3, 28, 2, 0, 0, 0, london), next)
+	// 01:00 - 02:00 is repeated on 2021-10-31
+	e = s.Task(nil).EveryThirtyMinutes().Between("01:00", "01:30")
+	var runs []string
+	for _, r := range e.Schedule().NextRuns(time.Date(2021, 10, 30, 1, 30, 0, 0, london), 3) {
+		runs = append(runs, r.UTC().Format("01-02 15:04"))
+	}
+	assert.Equal(t, []string{"10-31 00:00", "10-31 00:30", "10-31 01:00"}, runs)
+
+	// 00:00 - 01:00 is skipped on 2022-09-11 in Santiago
+	santiago, err := time.LoadLocation("America/Santiago")
+	assert.NoError(t, err)
+	s = NewScheduler(context.Background(), santiago)
+	next = s.Task(nil).Weekly().Schedule().Next(time.Date(2022, 9, 10, 12, 0, 0, 0, santiago))
+	assert.Equal(t, time.Date(2022, 9, 18, 0, 0, 0, 0, santiago), next)
+}
+
+func TestScheduler_pending(t *testing.T) {
+	clock := NewFakeClock(parseTime("2022-10-07 20:30:00"))
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(clock))
+	runs := s.DailyAt("09:00").Weekdays().NextRuns(1)
+	assert.Equal(t, parseTimes(t, "2006-01-02 15:04", "2022-10-10 09:00"), runs)
+	// the constraints of the finished chain don't leak into the next one
+	next := s.EveryFiveMinutes().Between("12:00", "20:00").Schedule().Next(clock.Now())
+	assert.Equal(t, parseTime("2022-10-08 12:00:00"), next)
+	assert.Empty(t, s.Event.limit.DaysOfWeek)
+	assert.Empty(t, s.Event.limit.StartTime)
+	assert.Empty(t, s.registered())
+}
+
+func TestScheduler_NextRuns(t *testing.T) {
+	clock := NewFakeClock(parseTime("2022-10-05 15:31:20"))
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(clock))
+	e := s.Task(nil).EveryThirtyMinutes().Mondays()
+	assert.Equal(t, parseTimes(t, "2006-01-02 15:04", "2022-10-10 00:00", "2022-10-10 00:30"), e.NextRuns(2))
+
+	// the runs are computed from the current time of clock, not the time the task is defined
+	clock.Advance(5*24*time.Hour + 30*time.Minute)
+	assert.Equal(t, parseTimes(t, "2006-01-02 15:04", "2022-10-10 16:30", "2022-10-10 17:00"), e.NextRuns(2))
+}
@@ -1,657 +1,706 @@
 // Package schedule
-// The core code of scheduler, contain frequency options and constraints.
+// The core code of scheduler, register the tasks and run them.
 package schedule
 
 import (
 	"context"
-	"github.com/golang-module/carbon/v2"
+	"errors"
 	"strconv"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
 // Scheduler The core scheduler struct
+// The embedded Event is the pending task definition of the `s.Daily().CallFunc(...)` chain,
+// it will be replaced with a new one after `Call`, `CallFunc`, `Schedule` and `NextRuns` called.
 type Scheduler struct {
+	*Event
 	location *time.Location
-	now      time.Time
+	current  time.Time
 	wg       sync.WaitGroup
 	ctx      context.Context
-	Next     *NextTick
-	limit    *Limit
 	count    int32
-	log      Logger
-}
-
-// NewScheduler create instance of scheduler with context and default time.location
-func NewScheduler(ctx context.Context, loc *time.Location) *Scheduler {
-	return &Scheduler{
+	log      StructuredLogger
+	metrics  Metrics
+	clock    Clock
+	mutex    Mutex
+	locks    LockStore
+	lastRuns LastRunStore
+	history  HistoryStore
+	grace    time.Duration
+	mu       sync.Mutex
+	events   []*Event
+	results  []Result
+	hooks    hooks
+	slots    chan struct{}
+	halt     chan struct{}
+	groups   map[string]chan struct{}
+}
+
+// New create instance of scheduler with context and options, the local time.location is used by default.
+// New(ctx, WithLocation(time.UTC), WithLockStore(store)) create a scheduler run the tasks in UTC.
+func New(ctx context.Context, opts ...Option) *Scheduler {
+	s := &Scheduler{
 		ctx:      ctx,
-		location: loc,
-		now:      time.Now().In(loc),
-		Next:     &NextTick{},
-		limit:    &Limit{},
+		location: time.Local,
 		count:    0,
 		log:      &DefaultLogger{},
+		clock:    SystemClock{},
+		metrics:  noopMetrics{},
+		mutex:    NewMemoryMutex(),
+		lastRuns: NewMemoryLastRunStore(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.current = s.clock.Now().In(s.location)
+	s.Event = s.newEvent()
+	return s
 }
 
-// Timezone set timezone with a new time.Location instance
-// after `Call` and `CallFunc` method called, the current time will roll back to default location.
-func (s *Scheduler) Timezone(loc *time.Location) *Scheduler {
-	s.now = s.now.In(loc)
-	return s
+// NewScheduler create instance of scheduler with context and default time.location
+func NewScheduler(ctx context.Context, loc *time.Location) *Scheduler {
+	return New(ctx, WithLocation(loc))
 }
 
-// SetLogger set a new logger
+// SetLogger set a new logger, the legacy logger is wrapped to log the fields in message
 func (s *Scheduler) SetLogger(l Logger) *Scheduler {
 	if l == nil {
 		return s
 	}
-	s.log = l
-	return s
-}
-
-// Start wait all task to be finished
-func (s *Scheduler) Start() {
-	if atomic.LoadInt32(&s.count) > 0 {
-		s.log.Debugf("Wait for %d tasks finish... \n", s.count)
-	}
-	s.wg.Wait()
-	s.log.Debug("All tasks have been finished.")
-}
-
-// Call call a task
-func (s *Scheduler) Call(t Task) {
-	defer s.Timezone(s.location)
-	if !s.isTimeMatched() {
-		return
-	}
-	if !s.checkLimit() {
-		return
-	}
-	atomic.AddInt32(&s.count, 1)
-	s.wg.Add(1)
-	go func() {
-		defer func() {
-			s.wg.Done()
-			atomic.AddInt32(&s.count, -1)
-			if r := recover(); r != nil {
-				s.log.Error("Recovering schedule task from panic:", r)
-			}
-		}()
-		t.Run(s.ctx)
-	}()
-}
-
-// CallFunc call a task function
-func (s *Scheduler) CallFunc(fn TaskFunc) {
-	s.Call(NewDefaultTask(fn))
-}
-
-func (s *Scheduler) isTimeMatched() bool {
-	if s.Next.Omit {
-		return false
-	}
-	if s.Next.Year == s.now.Year() &&
-		s.Next.Month == int(s.now.Month()) &&
-		s.Next.Day == s.now.Day() &&
-		s.Next.Hour == s.now.Hour() &&
-		s.Next.Minute == s.now.Minute() {
-		return true
-	}
-	return false
-}
-
-func (s *Scheduler) timeToMinutes(t string) (hour, minute int) {
-	var err error
-	hm := strings.Split(t, ":")
-	if len(hm) == 2 {
-		hour, err = strconv.Atoi(hm[0])
-		if err == nil {
-			minute, err = strconv.Atoi(hm[1])
-		}
-	}
-	if err != nil {
-		hour = 0
-		minute = 0
-	}
-	return
-}
-
-func (s *Scheduler) checkLimit() bool {
-	if len(s.limit.DaysOfWeek) > 0 {
-		var inDays bool
-		for _, day := range s.limit.DaysOfWeek {
-			if day == s.now.Weekday() {
-				inDays = true
-			}
-		}
-		if !inDays {
-			return false
-		}
-	}
-	var startMinute, endMinute int
-	var hour, minute int
-	if s.limit.StartTime != "" {
-		hour, minute = s.timeToMinutes(s.limit.StartTime)
-		startMinute = hour*60 + minute
-	}
-	if s.limit.EndTime != "" {
-		hour, minute = s.timeToMinutes(s.limit.EndTime)
-		endMinute = hour*60 + minute
-	}
-	if startMinute > endMinute {
-		temp := startMinute
-		startMinute = endMinute
-		endMinute = temp
-	}
-	minuteOffset := s.now.Hour()*60 + s.now.Minute()
-	if s.limit.IsBetween && (minuteOffset < startMinute || minuteOffset > endMinute) {
-		return false
-	} else if !s.limit.IsBetween && minuteOffset > startMinute && minuteOffset < endMinute {
-		return false
-	}
-
-	if s.limit.When != nil {
-		return s.limit.When(s.ctx)
-	}
-	return true
-}
-
-func (s *Scheduler) initNextTick() {
-	s.Next = &NextTick{
-		Year:   s.now.Year(),
-		Month:  int(s.now.Month()),
-		Day:    s.now.Day(),
-		Hour:   s.now.Hour(),
-		Minute: 0,
+	if sl, ok := l.(StructuredLogger); ok {
+		s.log = sl
+		return s
 	}
-}
-
-// EveryMinute run task every minutes
-func (s *Scheduler) EveryMinute() *Scheduler {
-	s.initNextTick()
-	s.Next.Minute = s.now.Minute()
+	s.log = &legacyLogger{l: l}
 	return s
 }
 
-// EveryTwoMinutes run task every two minutes
-func (s *Scheduler) EveryTwoMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%2 == 0 {
-		s.Next.Minute = minute
+// SetStructuredLogger set a new structured logger, use `NewSlogLogger` to integrate with log/slog
+func (s *Scheduler) SetStructuredLogger(l StructuredLogger) *Scheduler {
+	if l == nil {
+		return s
 	}
+	s.log = l
 	return s
 }
 
-// EveryThreeMinutes run task every three minutes
-func (s *Scheduler) EveryThreeMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%3 == 0 {
-		s.Next.Minute = minute
+// SetMutex set a new mutex for the tasks without overlapping
+func (s *Scheduler) SetMutex(m Mutex) *Scheduler {
+	if m == nil {
+		return s
 	}
+	s.mutex = m
 	return s
 }
 
-// EveryFourMinutes run task every four minutes
-func (s *Scheduler) EveryFourMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%4 == 0 {
-		s.Next.Minute = minute
+// SetLockStore set the lock store for the tasks run on one server
+func (s *Scheduler) SetLockStore(l LockStore) *Scheduler {
+	if l == nil {
+		return s
 	}
+	s.locks = l
 	return s
 }
 
-// EveryFiveMinutes run task every five minutes
-func (s *Scheduler) EveryFiveMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%5 == 0 {
-		s.Next.Minute = minute
+// SetClock set the clock of scheduler, the current time of scheduler is reset to the time of clock.
+// It should be set before the tasks defined, a FakeClock can be used to test the schedules.
+func (s *Scheduler) SetClock(c Clock) *Scheduler {
+	if c == nil {
+		return s
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = c
+	s.current = c.Now().In(s.location)
+	s.Event = s.newEvent()
 	return s
 }
 
-// EveryTenMinutes run the task every ten minutes
-func (s *Scheduler) EveryTenMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%10 == 0 {
-		s.Next.Minute = minute
+// SetMaxConcurrency set the max number of tasks run at the same time, the excess tasks wait in queue.
+// The concurrency is unlimited if it's not positive.
+func (s *Scheduler) SetMaxConcurrency(n int) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slots = nil
+	if n > 0 {
+		s.slots = make(chan struct{}, n)
 	}
 	return s
 }
 
-// EveryFifteenMinutes run the task every fifteen minutes
-func (s *Scheduler) EveryFifteenMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%15 == 0 {
-		s.Next.Minute = minute
+// SetMetrics set the metrics to track the task runs, use `NewPrometheusMetrics` to expose them to Prometheus
+func (s *Scheduler) SetMetrics(m Metrics) *Scheduler {
+	if m == nil {
+		return s
 	}
+	s.metrics = m
 	return s
 }
 
-// EveryThirtyMinutes run the task every thirty minutes
-func (s *Scheduler) EveryThirtyMinutes() *Scheduler {
-	s.initNextTick()
-	minute := s.now.Minute()
-	if minute%30 == 0 {
-		s.Next.Minute = minute
+// SetLastRunStore set the last run store for the tasks with `CatchUp`, the in-memory store is used by default
+func (s *Scheduler) SetLastRunStore(l LastRunStore) *Scheduler {
+	if l == nil {
+		return s
 	}
+	s.lastRuns = l
 	return s
 }
 
-// Hourly run the task every hour
-func (s *Scheduler) Hourly() *Scheduler {
-	s.initNextTick()
+// SetHistoryStore set the store to record the history of task runs, no history is recorded by default
+func (s *Scheduler) SetHistoryStore(h HistoryStore) *Scheduler {
+	s.history = h
 	return s
 }
 
-// HourlyAt run the task every hour at some minutes past the hour
-func (s *Scheduler) HourlyAt(t ...int) *Scheduler {
-	s.initNextTick()
-	s.Next.Omit = true
-	minute := s.now.Minute()
-	for _, v := range t {
-		if v >= 0 && v == minute {
-			s.Next.Minute = v
-			s.Next.Omit = false
-			break
-		}
-	}
+// SetGracePeriod set the max duration to wait the running tasks when the scheduler stops.
+// The scheduler waits all tasks to be finished if it's not positive.
+func (s *Scheduler) SetGracePeriod(d time.Duration) *Scheduler {
+	s.grace = d
 	return s
 }
 
-// EveryOddHour run the task every odd hour
-func (s *Scheduler) EveryOddHour() *Scheduler {
-	s.initNextTick()
-	s.Next.Omit = true
-	hour := s.now.Hour()
-	if hour >= 1 && hour <= 23 && hour%2 != 0 {
-		s.Next.Hour = hour
-		s.Next.Omit = false
-	}
+// BeforeEach register a hook to run before every task
+func (s *Scheduler) BeforeEach(fn HookFunc) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.before = append(s.hooks.before, fn)
 	return s
 }
 
-func (s *Scheduler) setHourlyInterval(n int) {
-	s.Next.Omit = true
-	hour := s.now.Hour()
-	if hour%n == 0 {
-		s.Next.Hour = hour
-		s.Next.Omit = false
-	}
-}
-
-// EveryTwoHours run the task every two hours
-func (s *Scheduler) EveryTwoHours() *Scheduler {
-	s.initNextTick()
-	s.setHourlyInterval(2)
+// AfterEach register a hook to run after every task, whether it succeeds or not
+func (s *Scheduler) AfterEach(fn HookFunc) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.after = append(s.hooks.after, fn)
 	return s
 }
 
-// EveryThreeHours run the task every three hours
-func (s *Scheduler) EveryThreeHours() *Scheduler {
-	s.initNextTick()
-	s.setHourlyInterval(3)
+// OnEachSuccess register a hook to run after every task succeeds
+func (s *Scheduler) OnEachSuccess(fn HookFunc) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.success = append(s.hooks.success, fn)
 	return s
 }
 
-// EveryFourHours run the task every four hours
-func (s *Scheduler) EveryFourHours() *Scheduler {
-	s.initNextTick()
-	s.setHourlyInterval(4)
+// OnEachFailure register a hook to run after every task fails, panics or timeouts
+func (s *Scheduler) OnEachFailure(fn HookFunc) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks.failure = append(s.hooks.failure, fn)
 	return s
 }
 
-// EveryFiveHours run the task every five hours
-func (s *Scheduler) EveryFiveHours() *Scheduler {
-	s.initNextTick()
-	s.setHourlyInterval(5)
-	return s
+// Task register a task function and return its definition.
+// The frequency and constraints of the returned event are scoped to this task only,
+// the task will be evaluated when `Start` or `Run` called.
+// s.Task(fn).Daily().Weekdays() run the task every weekday at midnight.
+func (s *Scheduler) Task(fn TaskFunc) *Event {
+	return s.Job(NewDefaultTask(fn))
 }
 
-// EverySixHours run the task every six hours
-func (s *Scheduler) EverySixHours() *Scheduler {
-	s.initNextTick()
-	s.setHourlyInterval(6)
-	return s
+// TaskE register a task function which can report its failure and return its definition.
+// s.TaskE(fn).Hourly().Retry(3, ExponentialBackoff(time.Second, time.Minute)) retry the failing task 3 times.
+func (s *Scheduler) TaskE(fn TaskFuncE) *Event {
+	return s.JobE(NewDefaultTaskE(fn))
 }
 
-// Daily run the task every day at midnight
-func (s *Scheduler) Daily() *Scheduler {
-	s.initNextTick()
-	s.Next.Hour = 0
-	return s
+// Job register a task and return its definition, it's same as `Task` but accept a Task instance.
+func (s *Scheduler) Job(t Task) *Event {
+	s.mu.Lock()
+	e := s.newEvent()
+	s.mu.Unlock()
+	e.task = t
+	s.register(e)
+	return e
 }
 
-func (s *Scheduler) setNextTime(t []string) {
-	currentHour := s.now.Hour()
-	currentMinute := s.now.Minute()
-	var hour, minute int
-	var err error
-	for _, v := range t {
-		hm := strings.Split(v, ":")
-		if len(hm) == 2 {
-			hour, err = strconv.Atoi(hm[0])
-			if err == nil {
-				minute, err = strconv.Atoi(hm[1])
-				if err == nil {
-					if currentHour == hour && currentMinute == minute {
-						s.Next.Hour = currentHour
-						s.Next.Minute = currentMinute
-						s.Next.Omit = false
-						break
-					}
-				}
-			}
-		}
-	}
+// JobE register a task which can report its failure and return its definition.
+func (s *Scheduler) JobE(t TaskE) *Event {
+	return s.Job(&errorTask{task: t})
 }
 
-// At run the task every day at some time (03:00 format), method alias of dailyAt
-func (s *Scheduler) At(t ...string) *Scheduler {
-	return s.DailyAt(t...)
+// Exec register a task to run an external command and return its definition, the task is named by the command line.
+// Use `JobE(NewCommandTask(...).WithEnv(...).WithDir(...))` to set the environment and working directory.
+func (s *Scheduler) Exec(path string, args ...string) *Event {
+	c := NewCommandTask(path, args...)
+	return s.JobE(c).Name(c.String())
 }
 
-// DailyAt run the task every day at some time (03:00 format)
-func (s *Scheduler) DailyAt(t ...string) *Scheduler {
-	s.initNextTick()
-	s.Next.Hour = 0
-	s.Next.Minute = 0
-	s.Next.Omit = true
-	s.setNextTime(t)
-	return s
+// Tasks return the information of all registered tasks in registration order
+func (s *Scheduler) Tasks() []TaskInfo {
+	events := s.registered()
+	tasks := make([]TaskInfo, 0, len(events))
+	for _, e := range events {
+		tasks = append(tasks, e.info())
+	}
+	return tasks
 }
 
-// TwiceDaily run the task daily at first and second hour
-func (s *Scheduler) TwiceDaily(first, second int) *Scheduler {
-	timeList := make([]string, 0, 2)
-	timeList = append(timeList, strconv.Itoa(first)+":00")
-	timeList = append(timeList, strconv.Itoa(second)+":00")
-	s.DailyAt(timeList...)
-	return s
-}
+// ErrTaskNotFound the error of running a task which is not registered
+var ErrTaskNotFound = errors.New("schedule: task not found")
 
-// TwiceDailyAt run the task daily at some time
-// TwiceDailyAt(1, 13, 15) run the task daily at 1:15 & 13:15
-func (s *Scheduler) TwiceDailyAt(first, second, offset int) *Scheduler {
-	timeList := make([]string, 0, 2)
-	timeList = append(timeList, strconv.Itoa(first)+":"+strconv.Itoa(offset))
-	timeList = append(timeList, strconv.Itoa(second)+":"+strconv.Itoa(offset))
-	s.DailyAt(timeList...)
-	return s
-}
+// ErrTaskSkipped the error of running a task which is skipped by its overlapping or server lock
+var ErrTaskSkipped = errors.New("schedule: task skipped")
 
-// Weekly run the task every Sunday at 00:00
-func (s *Scheduler) Weekly() *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	now = now.StartOfWeek()
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  now.Month(),
-		Day:    now.Day(),
-		Hour:   0,
-		Minute: 0,
+// RunTask run the registered task by name now regardless of its frequency and constraints, and wait it to be finished.
+// The locks of `WithoutOverlapping` and `OnOneServer` are still obtained, ErrTaskSkipped is returned if it's locked.
+// The error is a *RunError if the task failed, panicked, timed out or is still running after the grace period.
+func (s *Scheduler) RunTask(name string) (Result, error) {
+	var event *Event
+	for _, e := range s.registered() {
+		if e.info().Name == name {
+			event = e
+			break
+		}
 	}
-	return s
-}
-
-// WeeklyOn run the task every week on a time
-// WeeklyOn(1, "8:00") run the task every week on Monday at 8:00
-func (s *Scheduler) WeeklyOn(d time.Weekday, t string) *Scheduler {
-	s.Next = &NextTick{
-		Year:   s.now.Year(),
-		Month:  int(s.now.Month()),
-		Day:    0,
-		Hour:   0,
-		Minute: 0,
-		Omit:   true,
+	if event == nil {
+		return Result{Name: name, Status: StatusPending}, ErrTaskNotFound
 	}
-	if s.now.Weekday() == d {
-		s.Next.Day = s.now.Day()
-		s.setNextTime([]string{t})
+	s.dispatch(event, s.clock.Now())
+	s.wait()
+	result := Result{Name: name, Status: StatusPending}
+	found := false
+	for _, r := range s.takeResults() {
+		if r.Name == name {
+			result, found = r, true
+		}
 	}
-	return s
-}
-
-// Monthly run the task on the first day of every month at 00:00
-func (s *Scheduler) Monthly() *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	now = now.StartOfMonth()
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  now.Month(),
-		Day:    now.Day(),
-		Hour:   0,
-		Minute: 0,
+	if !found {
+		if !event.isRunning() {
+			return result, ErrTaskSkipped
+		}
+		result.Status = StatusRunning
+	}
+	return result, runError([]Result{result})
+}
+
+// Start run the tasks registered by `Task` and `Job` if they are due, and wait all task to be finished.
+// It returns the names of tasks still running if the grace period is over.
+func (s *Scheduler) Start() []string {
+	s.evaluatePending()
+	running := s.wait()
+	s.takeResults()
+	return running
+}
+
+// Wait run the tasks registered by `Task` and `Job` if they are due like `Start`, wait all task to be finished
+// and return the results of the task runs since last `Start` or `Wait`.
+// The error is a *RunError if any task failed, panicked, timed out or is still running after the grace period.
+func (s *Scheduler) Wait() ([]Result, error) {
+	s.evaluatePending()
+	running := s.wait()
+	results := s.takeResults()
+	for _, name := range running {
+		results = append(results, Result{Name: name, Status: StatusRunning})
+	}
+	return results, runError(results)
+}
+
+// Run run the scheduler as a long-running daemon instead of crontab.
+// The registered tasks are re-evaluated on every minute boundary until the context is done,
+// then it waits all running tasks to be finished, the names of tasks still running after the grace period are returned.
+func (s *Scheduler) Run(ctx context.Context) []string {
+	s.logAt(LevelInfo, "Scheduler is running in daemon mode")
+	halt := make(chan struct{})
+	s.mu.Lock()
+	s.halt = halt
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.halt = nil
+		s.mu.Unlock()
+	}()
+	// the tasks are evaluated at the minute the daemon starts, the time they are defined may be stale
+	s.evaluatePendingAt(s.clock.Now().Truncate(time.Minute))
+	for {
+		now := s.clock.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// stop repeating the sub-minute tasks
+			close(halt)
+			return s.wait()
+		case <-timer.C:
+			// the results are not collected in daemon mode
+			s.takeResults()
+			s.tick(next)
+		}
 	}
-	return s
 }
 
-// MonthlyOn run the task every month on a time
-// MonthlyOn(4, "15:00") run the task every month on the 4th at 15:00
-func (s *Scheduler) MonthlyOn(d int, t string) *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  now.Month(),
-		Day:    0,
-		Hour:   0,
-		Minute: 0,
-		Omit:   true,
-	}
-	if now.DayOfMonth() == d {
-		s.Next.Day = now.Day()
-		s.setNextTime([]string{t})
+func (s *Scheduler) wait() []string {
+	if n := atomic.LoadInt32(&s.count); n > 0 {
+		s.logAt(LevelDebug, "Wait for tasks to finish", Field{"count", n})
 	}
-	return s
-}
-
-// TwiceMonthly run the task monthly on some time
-// TwiceMonthly(1, 16, "13:00") run the task monthly on the 1st and 16th at 13:00
-func (s *Scheduler) TwiceMonthly(b, e int, t string) *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  now.Month(),
-		Day:    0,
-		Hour:   0,
-		Minute: 0,
-		Omit:   true,
+	if s.grace <= 0 {
+		s.wg.Wait()
+		s.logAt(LevelDebug, "All tasks have been finished")
+		return nil
 	}
-	day := now.DayOfMonth()
-	if day == b || day == e {
-		s.Next.Day = day
-		s.setNextTime([]string{t})
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(s.grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		s.logAt(LevelDebug, "All tasks have been finished")
+		return nil
+	case <-timer.C:
+		running := s.running()
+		s.logAt(LevelWarn, "Stop waiting for the running tasks after the grace period",
+			Field{"grace_period", s.grace}, Field{"tasks", running})
+		return running
+	}
+}
+
+// running return the names of running tasks, the sub-minute tasks with pending repetitions are included
+func (s *Scheduler) running() []string {
+	var names []string
+	for _, e := range s.registered() {
+		if e.isRunning() || e.isRepeating() {
+			names = append(names, e.info().Name)
+		}
 	}
-	return s
+	return names
 }
 
-// LastDayOfMonth run the task on the last day of the month at a time
-// LastDayOfMonth("15:00") run the task on the last day of the month at 15:00
-func (s *Scheduler) LastDayOfMonth(t string) *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  now.Month(),
-		Day:    now.EndOfMonth().Day(),
-		Hour:   0,
-		Minute: 0,
-		Omit:   true,
-	}
-	if t != "" {
-		s.setNextTime([]string{t})
-	}
-	return s
+func (s *Scheduler) record(r Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, r)
 }
 
-// Quarterly Run the task on the first day of every quarter at 00:00
-func (s *Scheduler) Quarterly() *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	qs := now.StartOfQuarter()
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  qs.Month(),
-		Day:    qs.Day(),
-		Hour:   0,
-		Minute: 0,
-	}
-	return s
+func (s *Scheduler) takeResults() []Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := s.results
+	s.results = nil
+	return results
 }
 
-// Yearly run the task on the first day of every year at 00:00
-func (s *Scheduler) Yearly() *Scheduler {
-	s.Next = &NextTick{
-		Year:   s.now.Year(),
-		Month:  1,
-		Day:    1,
-		Hour:   0,
-		Minute: 0,
+func (s *Scheduler) newEvent() *Event {
+	return &Event{
+		scheduler: s,
+		ctx:       s.ctx,
+		now:       s.current.In(s.location),
+		Next:      &NextTick{},
+		limit:     &Limit{},
+		status:    StatusPending,
 	}
-	return s
 }
 
-// YearlyOn Run the task every year on a time
-// YearlyOn(6, 1, "17:00") run the task every year on June 1st at 17:00
-func (s *Scheduler) YearlyOn(m, d int, t string) *Scheduler {
-	now := carbon.Time2Carbon(s.now)
-	s.Next = &NextTick{
-		Year:   now.Year(),
-		Month:  0,
-		Day:    0,
-		Hour:   0,
-		Minute: 0,
-		Omit:   true,
+func (s *Scheduler) register(e *Event) {
+	s.finishPending(e)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.events {
+		if v == e {
+			return
+		}
 	}
-	month := now.Month()
-	day := now.Day()
-	if month == m && day == d {
-		s.Next.Month = month
-		s.Next.Day = d
+	e.mu.Lock()
+	if e.name == "" {
+		e.name = "task-" + strconv.Itoa(len(s.events)+1)
 	}
-	if t != "" {
-		s.setNextTime([]string{t})
+	name := e.name
+	e.mu.Unlock()
+	if s.nameTaken(e, name) {
+		s.logAt(LevelError, "Duplicate task name", Field{FieldTask, name})
 	}
-	return s
+	s.events = append(s.events, e)
 }
 
-// Weekdays limit the task to weekdays
-func (s *Scheduler) Weekdays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Monday,
-		time.Tuesday,
-		time.Wednesday,
-		time.Thursday,
-		time.Friday,
-	)
-	return s
+// checkName log the error if the name of registered task e is used by another registered task
+func (s *Scheduler) checkName(e *Event, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	registered := false
+	for _, v := range s.events {
+		registered = registered || v == e
+	}
+	if registered && s.nameTaken(e, name) {
+		s.logAt(LevelError, "Duplicate task name", Field{FieldTask, name})
+	}
 }
 
-// Weekends limit the task to weekends
-func (s *Scheduler) Weekends() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Saturday,
-		time.Sunday,
-	)
-	return s
+// nameTaken check the name is used by a registered task other than e, s.mu must be held
+func (s *Scheduler) nameTaken(e *Event, name string) bool {
+	for _, v := range s.events {
+		if v == e {
+			continue
+		}
+		v.mu.Lock()
+		taken := v.name == name
+		v.mu.Unlock()
+		if taken {
+			return true
+		}
+	}
+	return false
 }
 
-// Mondays limit the task to Monday
-func (s *Scheduler) Mondays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Monday,
-	)
-	return s
+// finishPending replace the pending task definition with a new one if it's e,
+// so the frequency and constraints of e don't leak into the next `s.Daily()...` chain.
+func (s *Scheduler) finishPending(e *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e == s.Event {
+		s.Event = s.newEvent()
+	}
 }
 
-// Tuesdays limit the task to Tuesday
-func (s *Scheduler) Tuesdays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Tuesday,
-	)
-	return s
+func (s *Scheduler) registered() []*Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Event(nil), s.events...)
 }
 
-// Wednesdays limit the task to Wednesday
-func (s *Scheduler) Wednesdays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Wednesday,
-	)
-	return s
+// evaluatePending run the tasks which have not been evaluated at the time they are defined
+func (s *Scheduler) evaluatePending() {
+	for _, e := range s.registered() {
+		matched, allowed := e.evaluate()
+		s.fire(e, e.now, matched, allowed)
+	}
 }
 
-// Thursdays limit the task to Thursday
-func (s *Scheduler) Thursdays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Thursday,
-	)
-	return s
+// evaluatePendingAt run the tasks which have not been evaluated at now instead of the time they are defined
+func (s *Scheduler) evaluatePendingAt(now time.Time) {
+	s.mu.Lock()
+	s.current = now
+	s.mu.Unlock()
+	for _, e := range s.registered() {
+		if e.markEvaluated() {
+			s.check(e, now)
+		}
+	}
 }
 
-// Fridays limit the task to Friday
-func (s *Scheduler) Fridays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Friday,
-	)
-	return s
+func (s *Scheduler) tick(now time.Time) {
+	s.mu.Lock()
+	s.current = now
+	s.mu.Unlock()
+	for _, e := range s.registered() {
+		s.check(e, now)
+	}
 }
 
-// Saturdays limit the task to Saturday
-func (s *Scheduler) Saturdays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Saturday,
-	)
-	return s
+// check fire the task if it's due at now
+func (s *Scheduler) check(e *Event, now time.Time) {
+	sc := e.Schedule()
+	matched := sc.isTimeMatched(now)
+	s.fire(e, now, matched, matched && sc.limit.check(s.ctx, now.In(sc.location)))
 }
 
-// Sundays limit the task to Sunday
-func (s *Scheduler) Sundays() *Scheduler {
-	s.limit.DaysOfWeek = append(
-		s.limit.DaysOfWeek,
-		time.Sunday,
-	)
-	return s
+// fire dispatch the task if its frequency and constraints are matched,
+// the task skipped by the constraints is reported to metrics, the missed runs are caught up before it.
+func (s *Scheduler) fire(e *Event, at time.Time, matched, allowed bool) {
+	s.catchUp(e, at, matched && allowed)
+	if !matched {
+		return
+	}
+	// the occurrence skipped by constraints is handled, only the one missed during downtime is caught up
+	if name, window, _ := e.catchUpPolicy(); window > 0 {
+		s.setLastRun(name, at)
+	}
+	if !allowed {
+		s.skip(e.info().Name, SkipConstraint)
+		return
+	}
+	if seconds := e.subMinute(); seconds > 0 {
+		s.repeat(e, at, seconds)
+	} else {
+		s.dispatch(e, at)
+	}
 }
 
-// Days limit the task to specific days
-func (s *Scheduler) Days(d ...time.Weekday) *Scheduler {
-	s.limit.DaysOfWeek = append(s.limit.DaysOfWeek, d...)
-	return s
+// repeat run the sub-minute task at the seconds of the bit set until the end of minute,
+// the latest second already passed is dispatched immediately.
+// The constraints are checked before every repetition.
+func (s *Scheduler) repeat(e *Event, at time.Time, seconds uint64) {
+	start := at.Truncate(time.Minute)
+	elapsed := s.clock.Now().Sub(start)
+	if elapsed < 0 || elapsed >= time.Minute {
+		elapsed = at.Sub(start)
+	}
+	now := start.Add(elapsed)
+	var slots []time.Time
+	for i := 0; i < 60; i++ {
+		if seconds&(1<<uint(i)) == 0 {
+			continue
+		}
+		slot := start.Add(time.Duration(i) * time.Second)
+		if slot.After(now) {
+			slots = append(slots, slot)
+		} else {
+			// only the latest second passed is kept
+			slots = append(slots[:0], slot)
+		}
+	}
+	if len(slots) > 0 && !slots[0].After(now) {
+		s.dispatch(e, slots[0])
+		slots = slots[1:]
+	}
+	if len(slots) == 0 {
+		return
+	}
+	s.mu.Lock()
+	halt := s.halt
+	s.mu.Unlock()
+	sc := e.Schedule()
+	s.wg.Add(1)
+	e.setRepeating(1)
+	go func() {
+		defer s.wg.Done()
+		defer e.setRepeating(-1)
+		for _, next := range slots {
+			timer := time.NewTimer(next.Sub(s.clock.Now()))
+			select {
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			case <-halt:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if !sc.limit.check(s.ctx, next.In(sc.location)) {
+				s.skip(e.info().Name, SkipConstraint)
+				continue
+			}
+			s.dispatch(e, next)
+		}
+	}()
 }
 
-// Between limit the task to run between start and end time
-func (s *Scheduler) Between(start, end string) *Scheduler {
-	s.limit.StartTime = start
-	s.limit.EndTime = end
-	s.limit.IsBetween = true
-	return s
+// skip report the task is skipped with the reason
+func (s *Scheduler) skip(name, reason string) {
+	s.logAt(LevelDebug, "Skip task", Field{FieldTask, name}, Field{"reason", reason})
+	s.metrics.TaskSkipped(name, reason)
 }
 
-// UnlessBetween limit the task to not run between start and end time
-func (s *Scheduler) UnlessBetween(start, end string) *Scheduler {
-	s.limit.StartTime = start
-	s.limit.EndTime = end
-	s.limit.IsBetween = false
-	return s
+// dispatch run the task in go routine, at is the time the task is scheduled
+func (s *Scheduler) dispatch(e *Event, at time.Time) {
+	name, expiry, oneServer, subMinute := e.locking()
+	if oneServer && !s.obtainServer(name, oneServerKey(name, at, subMinute)) {
+		return
+	}
+	if expiry > 0 {
+		ok, err := s.mutex.Lock(name, expiry)
+		if err != nil {
+			s.logAt(LevelError, "Failed to lock task", Field{FieldTask, name}, Field{FieldError, err})
+			s.skip(name, SkipLockError)
+			return
+		}
+		if !ok {
+			s.skip(name, SkipOverlapping)
+			return
+		}
+	}
+	atomic.AddInt32(&s.count, 1)
+	s.wg.Add(1)
+	queued := time.Now()
+	spec := e.start(queued)
+	name = spec.name
+	s.metrics.TaskStarted(name)
+	runID := newRunID()
+	s.mu.Lock()
+	h := s.hooks.wrap(spec.hooks)
+	s.mu.Unlock()
+	go func() {
+		var err error
+		var overrun *time.Timer
+		started := queued
+		closeOutput := func() error { return nil }
+		defer func() {
+			r := recover()
+			result := e.finish(started, err, r, overrun != nil && !overrun.Stop())
+			result.RunID = runID
+			result.Scheduled = at
+			result.QueueWait = started.Sub(queued)
+			s.record(result)
+			s.metrics.TaskFinished(result)
+			s.saveHistory(result)
+			fields := []Field{{FieldTask, name}, {FieldRunID, runID}, {FieldDuration, result.Duration}}
+			switch {
+			case r != nil:
+				s.logAt(LevelError, "Recovering task from panic", append(fields, Field{"panic", r})...)
+			case err != nil:
+				s.logAt(LevelError, "Task failed", append(fields, Field{FieldError, err})...)
+			default:
+				s.logAt(LevelDebug, "Task finished", append(fields, Field{"status", result.Status})...)
+			}
+			if result.Failed() {
+				s.runHooks(h.failure, result)
+			} else {
+				s.runHooks(h.success, result)
+			}
+			s.runHooks(h.after, result)
+			if err := closeOutput(); err != nil {
+				s.logAt(LevelError, "Failed to close the output of task", Field{FieldTask, name}, Field{FieldError, err})
+			}
+			if expiry > 0 {
+				if err := s.mutex.Unlock(name); err != nil {
+					s.logAt(LevelError, "Failed to unlock task", Field{FieldTask, name}, Field{FieldError, err})
+				}
+			}
+			s.wg.Done()
+			atomic.AddInt32(&s.count, -1)
+		}()
+		var release func()
+		var blocked bool
+		if release, blocked, err = s.acquire(spec.group); err != nil {
+			return
+		}
+		defer release()
+		started = time.Now()
+		if blocked {
+			s.logAt(LevelInfo, "Task waited in the concurrency queue", Field{FieldTask, name}, Field{FieldRunID, runID},
+				Field{"group", spec.group}, Field{"queue_wait", started.Sub(queued)})
+		}
+		ctx, cancel := s.ctx, context.CancelFunc(func() {})
+		if spec.timeout > 0 {
+			ctx, cancel = context.WithTimeout(s.ctx, spec.timeout)
+			overrun = time.AfterFunc(spec.timeout, func() {
+				s.logAt(LevelWarn, "Task exceeded its timeout",
+					Field{FieldTask, name}, Field{FieldRunID, runID}, Field{"timeout", spec.timeout})
+			})
+		}
+		defer cancel()
+		if spec.output.isSet() {
+			if w, c, err := spec.output.open(); err != nil {
+				s.logAt(LevelError, "Failed to open the output of task", Field{FieldTask, name}, Field{FieldError, err})
+			} else {
+				ctx, closeOutput = withOutput(ctx, w), c
+			}
+		}
+		s.logAt(LevelDebug, "Task started", Field{FieldTask, name}, Field{FieldRunID, runID})
+		s.runHooks(h.before, Result{Name: name, RunID: runID, Status: StatusRunning, Start: started})
+		err = e.run(ctx, name, runID, spec.task)
+	}()
 }
 
-// When limit the task based on a truth test
-func (s *Scheduler) When(when WhenFunc) *Scheduler {
-	s.limit.When = when
-	return s
+func (s *Scheduler) obtainServer(name, key string) bool {
+	if s.locks == nil {
+		s.logAt(LevelError, "Failed to obtain the server lock of task", Field{FieldTask, name}, Field{FieldError, ErrNoLockStore})
+		s.skip(name, SkipLockError)
+		return false
+	}
+	ok, err := s.locks.Obtain(s.ctx, key, OneServerLockExpiry)
+	if err != nil {
+		s.logAt(LevelError, "Failed to obtain the server lock of task", Field{FieldTask, name}, Field{FieldError, err})
+		s.skip(name, SkipLockError)
+		return false
+	}
+	if !ok {
+		s.skip(name, SkipOneServer)
+	}
+	return ok
 }
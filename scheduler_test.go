@@ -3,7 +3,11 @@ package schedule
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -35,18 +39,17 @@ func TestScheduler_isTimeMatched(t *testing.T) {
 }
 
 func TestScheduler_timeToMinutes(t *testing.T) {
-	s := NewScheduler(context.Background(), time.UTC)
 	var hour, minute int
-	hour, minute = s.timeToMinutes("a:b")
+	hour, minute = timeToMinutes("a:b")
 	assert.Zero(t, hour)
 	assert.Zero(t, minute)
-	hour, minute = s.timeToMinutes("a:1")
+	hour, minute = timeToMinutes("a:1")
 	assert.Zero(t, hour)
 	assert.Zero(t, minute)
-	hour, minute = s.timeToMinutes("1:b")
+	hour, minute = timeToMinutes("1:b")
 	assert.Zero(t, hour)
 	assert.Zero(t, minute)
-	hour, minute = s.timeToMinutes("1:1")
+	hour, minute = timeToMinutes("1:1")
 	assert.Equal(t, 1, hour)
 	assert.Equal(t, 1, minute)
 }
@@ -211,11 +214,11 @@ func TestScheduler_checkLimit(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &Scheduler{
+			e := &Event{
 				now:   tt.fields.now,
 				limit: tt.fields.limit,
 			}
-			assert.Equalf(t, tt.want, s.checkLimit(), "checkLimit()")
+			assert.Equalf(t, tt.want, e.checkLimit(), "checkLimit()")
 		})
 	}
 }
@@ -232,6 +235,108 @@ func TestScheduler_EveryMinute(t *testing.T) {
 	assert.True(t, marked)
 }
 
+func TestScheduler_EverySeconds(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	tests := []struct {
+		e    *Event
+		desc string
+		expr string
+	}{
+		{s.Task(nil).EverySecond(), "EverySecond()", "* * * * * *"},
+		{s.Task(nil).EveryTwoSeconds(), "EveryTwoSeconds()", "*/2 * * * * *"},
+		{s.Task(nil).EveryFiveSeconds(), "EveryFiveSeconds()", "*/5 * * * * *"},
+		{s.Task(nil).EveryTenSeconds(), "EveryTenSeconds()", "*/10 * * * * *"},
+		{s.Task(nil).EveryFifteenSeconds(), "EveryFifteenSeconds()", "*/15 * * * * *"},
+		{s.Task(nil).EveryTwentySeconds(), "EveryTwentySeconds()", "*/20 * * * * *"},
+		{s.Task(nil).EveryThirtySeconds(), "EveryThirtySeconds()", "*/30 * * * * *"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.desc, tt.e.desc)
+		assert.Equal(t, tt.expr, tt.e.expr)
+		assert.True(t, tt.e.isTimeMatched())
+		c, err := ParseCron(tt.e.expr)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Second, tt.e.subMinute())
+	}
+	assert.Equal(t, uint64(1|1<<30), s.Task(nil).EveryThirtySeconds().subMinute())
+	assert.Equal(t, uint64(0), s.Task(nil).EveryFiveSeconds().Hourly().subMinute())
+}
+
+func TestScheduler_repeat(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05.000", "2022-10-05 15:30:54.950")
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	s.Task(func(ctx context.Context) {}).Name("heartbeat").EveryFiveSeconds()
+	results, err := s.Wait()
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	scheduled := []string{results[0].Scheduled.Format("15:04:05"), results[1].Scheduled.Format("15:04:05")}
+	assert.ElementsMatch(t, []string{"15:30:50", "15:30:55"}, scheduled)
+
+	// the constraints are checked before every repetition
+	now, _ = time.Parse("2006-01-02 15:04:05.000", "2022-10-05 15:30:29.950")
+	logger := &recordLogger{}
+	s = New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)), WithStructuredLogger(logger))
+	var checks int32
+	s.Task(func(ctx context.Context) {}).Name("poll").EveryThirtySeconds().When(func(ctx context.Context) bool {
+		return atomic.AddInt32(&checks, 1) == 1
+	})
+	results, err = s.Wait()
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&checks))
+	assert.NotNil(t, logger.find("Skip task"))
+}
+
+func TestScheduler_CronSeconds(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05.000", "2022-10-05 15:30:29.950")
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	e := s.Task(func(ctx context.Context) {}).Name("sync").Cron("10,30 * * * * *")
+	assert.Equal(t, uint64(1<<10|1<<30), e.subMinute())
+	var runs []string
+	for _, r := range e.NextRuns(3) {
+		runs = append(runs, r.Format("15:04:05"))
+	}
+	assert.Equal(t, []string{"15:30:30", "15:31:10", "15:31:30"}, runs)
+	results, err := s.Wait()
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	scheduled := []string{results[0].Scheduled.Format("15:04:05"), results[1].Scheduled.Format("15:04:05")}
+	assert.ElementsMatch(t, []string{"15:30:10", "15:30:30"}, scheduled)
+
+	// the task of second 0 runs once a minute
+	assert.Equal(t, uint64(0), s.Task(nil).Cron("0 30 * * * *").subMinute())
+	assert.Equal(t, uint64(0), s.Task(nil).Cron("30 * * * * *").Hourly().subMinute())
+}
+
+func TestScheduler_repeat_stop(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	var count int32
+	s.Task(func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+	}).EveryThirtySeconds()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	started := time.Now()
+	assert.Empty(t, s.Run(ctx))
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	s = New(ctx, WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	s.Task(func(ctx context.Context) {}).EverySecond()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	results, err := s.Wait()
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
 func TestScheduler_EveryTwoMinutes(t *testing.T) {
 	s := NewScheduler(context.Background(), time.UTC)
 	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:01")
@@ -429,6 +534,194 @@ func TestScheduler_EverySixHours(t *testing.T) {
 	assert.False(t, s.Next.Omit)
 }
 
+func formatRuns(runs []time.Time) []string {
+	list := make([]string, 0, len(runs))
+	for _, r := range runs {
+		list = append(list, r.Format("01-02 15:04"))
+	}
+	return list
+}
+
+func TestScheduler_EveryHoursAt(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	from := time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC)
+	e := s.Task(nil).EverySixHoursAt(17)
+	assert.Equal(t, "EverySixHoursAt(17)", e.desc)
+	assert.Equal(t, "17 */6 * * *", e.expr)
+	assert.Equal(t, []string{"10-05 00:17", "10-05 06:17", "10-05 12:17"}, formatRuns(e.Schedule().NextRuns(from, 3)))
+	e = s.Task(nil).EveryOddHourAt(5, 35, 60)
+	assert.Equal(t, "5,35 1-23/2 * * *", e.expr)
+	assert.Equal(t, []string{"10-05 01:05", "10-05 01:35", "10-05 03:05"}, formatRuns(e.Schedule().NextRuns(from, 3)))
+	assert.Equal(t, "10 */2 * * *", s.Task(nil).EveryTwoHoursAt(10).expr)
+	assert.Equal(t, "10 */3 * * *", s.Task(nil).EveryThreeHoursAt(10).expr)
+	assert.Equal(t, "10 */4 * * *", s.Task(nil).EveryFourHoursAt(10).expr)
+	e = s.Task(nil).EveryFiveHoursAt(10)
+	assert.Equal(t, "10 */5 * * *", e.expr)
+	assert.Equal(t, []string{"10-05 20:10", "10-06 00:10"}, formatRuns(e.Schedule().NextRuns(from.Add(16*time.Hour), 2)))
+	assert.Empty(t, s.Task(nil).EverySixHoursAt(60).expr)
+
+	logger := &recordLogger{}
+	s.SetStructuredLogger(logger)
+	e = s.Task(nil).EveryTwoHoursAt(70)
+	assert.True(t, e.Next.Omit)
+	entry := logger.find("Invalid minute of frequency")
+	assert.NotNil(t, entry)
+	assert.Equal(t, LevelError, entry.level)
+	assert.Equal(t, "EveryTwoHoursAt(70)", entry.fields["frequency"])
+}
+
+func TestSpreadMinute(t *testing.T) {
+	assert.Equal(t, SpreadMinute("backup"), SpreadMinute("backup"))
+	minutes := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		m := SpreadMinute("task-" + strconv.Itoa(i))
+		assert.True(t, m >= 0 && m < 60)
+		minutes[m] = true
+	}
+	assert.Greater(t, len(minutes), 10)
+}
+
+func TestEvent_Spread(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	from := time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC)
+	offset := SpreadMinute("backup")
+	assert.Equal(t, 59, offset)
+	e := s.Task(nil).Name("backup").EverySixHours().Spread().Spread()
+	runs := e.Schedule().NextRuns(from.Add(-time.Minute), 2)
+	assert.Equal(t, from.Add(time.Duration(offset)*time.Minute), runs[0])
+	assert.Equal(t, 6*time.Hour, runs[1].Sub(runs[0]))
+	assert.Equal(t, strconv.Itoa(offset)+" */6 * * *", e.expr)
+	assert.Contains(t, e.info().Constraints, "Spread("+strconv.Itoa(offset)+"m)")
+
+	// the constraints are checked at the original fire time, the offset of backup is 59 minutes
+	monday := time.Date(2022, 10, 10, 23, 50, 0, 0, time.UTC)
+	shift := time.Duration(offset) * time.Minute
+	d := s.Task(nil).Name("backup").DailyAt("23:50").Mondays().Spread()
+	runs = d.Schedule().NextRuns(monday.Add(-24*time.Hour), 2)
+	assert.Equal(t, []time.Time{monday.Add(shift), monday.Add(7*24*time.Hour + shift)}, runs)
+	assert.False(t, d.Schedule().limit.allow(monday.Add(-24*time.Hour+shift)))
+	d.Daily()
+	assert.Equal(t, time.Duration(0), d.limit.shift)
+
+	// the offset is reset by a new frequency
+	e.Daily()
+	assert.Equal(t, "0 0 * * *", e.expr)
+	assert.Empty(t, e.info().Constraints)
+
+	logger := &recordLogger{}
+	s.SetStructuredLogger(logger)
+	s.Spread()
+	assert.Nil(t, logger.find("Spread the task without name, name it first to get a stable offset"))
+	s.Hourly().Spread()
+	assert.NotNil(t, logger.find("Spread the task without name, name it first to get a stable offset"))
+}
+
+func TestShiftCron(t *testing.T) {
+	assert.Equal(t, "17 */6 * * *; 47 1 * * *", shiftCron("0 */6 * * *; 30 1 * * *", 17))
+	assert.Equal(t, "17,47 * * * *", shiftCron("0,30 * * * *", 17))
+	assert.Equal(t, "", shiftCron("50 23 * * *", 17))
+	assert.Equal(t, "", shiftCron("*/5 * * * *", 3))
+	assert.Equal(t, "", shiftCron("*/5 * * * * *", 3))
+	assert.Equal(t, "*/5 * * * *", shiftCron("*/5 * * * *", 0))
+	assert.Equal(t, "", shiftCron("", 3))
+}
+
+func TestScheduler_EveryNMinutes(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	anchor := time.Date(2022, 10, 5, 23, 50, 30, 0, time.UTC)
+	e := s.Task(nil).EveryNMinutes(7, anchor)
+	assert.Equal(t, "EveryNMinutes(7, 2022-10-05T23:50:30Z)", e.desc)
+	assert.Empty(t, e.expr)
+	assert.Equal(t, []string{"10-05 23:57", "10-06 00:04", "10-06 00:11"}, formatRuns(e.Schedule().NextRuns(anchor, 3)))
+	// the intervals start at the anchor
+	nine := time.Date(2022, 10, 5, 9, 0, 0, 0, time.UTC)
+	e = s.Task(nil).EveryNMinutes(7, nine)
+	assert.Equal(t, []string{"10-05 09:00", "10-05 09:07", "10-05 09:14"}, formatRuns(e.Schedule().NextRuns(nine.Add(-time.Hour), 3)))
+	assert.False(t, e.Schedule().isTimeMatched(nine.Add(-7*time.Minute)))
+
+	// the default anchor is the midnight of 1970-01-01
+	from := time.Date(2022, 10, 5, 23, 0, 0, 0, time.UTC)
+	runs := s.Task(nil).EveryNMinutes(7).Schedule().NextRuns(from, 20)
+	for i := 1; i < len(runs); i++ {
+		assert.Equal(t, 7*time.Minute, runs[i].Sub(runs[i-1]))
+	}
+	assert.Equal(t, 0, int(runs[0].Unix()/60%7))
+
+	assert.Equal(t, "*/15 * * * *", s.Task(nil).EveryNMinutes(15).expr)
+	assert.Equal(t, "* * * * *", s.Task(nil).EveryNMinutes(1).expr)
+	assert.Equal(t, "0 * * * *", s.Task(nil).EveryNMinutes(60).expr)
+	assert.Equal(t, []string{"10-06 00:00", "10-06 01:00"}, formatRuns(s.Task(nil).EveryNMinutes(60).Schedule().NextRuns(from, 2)))
+	assert.Empty(t, s.Task(nil).EveryNMinutes(7).expr)
+
+	logger := &recordLogger{}
+	s.SetStructuredLogger(logger)
+	e = s.Task(nil).EveryNMinutes(0)
+	assert.True(t, e.Next.Omit)
+	assert.Equal(t, "EveryNMinutes(0)", e.desc)
+	assert.NotNil(t, logger.find("Invalid interval of frequency"))
+}
+
+func TestScheduler_EveryNHours(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	anchor := time.Date(2022, 10, 5, 6, 30, 0, 0, time.UTC)
+	e := s.Task(nil).EveryNHours(8, anchor)
+	assert.Equal(t, "EveryNHours(8, 2022-10-05T06:30:00Z)", e.desc)
+	assert.Equal(t, []string{"10-05 14:30", "10-05 22:30", "10-06 06:30", "10-06 14:30"},
+		formatRuns(e.Schedule().NextRuns(anchor, 4)))
+
+	// the intervals are periodic across days, not reset at midnight like `EveryFiveHours`
+	from := time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC)
+	runs := s.Task(nil).EveryNHours(5).Schedule().NextRuns(from, 10)
+	for i := 1; i < len(runs); i++ {
+		assert.Equal(t, 5*time.Hour, runs[i].Sub(runs[i-1]))
+	}
+
+	prc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	e = s.Task(nil).Timezone(prc).EveryNHours(6)
+	assert.Equal(t, "0 */6 * * *", e.expr)
+	assert.Equal(t, []string{"10-05 12:00", "10-05 18:00"}, formatRuns(e.Schedule().NextRuns(from.In(prc), 2)))
+	assert.Empty(t, s.Task(nil).EveryNHours(5).expr)
+	assert.Empty(t, s.Task(nil).EveryNHours(6, anchor).expr)
+	assert.Equal(t, "0 0 * * *", s.Task(nil).EveryNHours(24).expr)
+	assert.Equal(t, []string{"10-06 00:00", "10-07 00:00"}, formatRuns(s.Task(nil).EveryNHours(24).Schedule().NextRuns(from, 2)))
+
+	// the intervals are counted from the local midnight of 1970-01-01, so they are on the hour in half-hour zones
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	assert.NoError(t, err)
+	runs = s.Task(nil).Timezone(kolkata).EveryNHours(5).Schedule().NextRuns(from.In(kolkata), 3)
+	assert.Len(t, runs, 3)
+	for _, r := range runs {
+		assert.Equal(t, 0, r.Minute())
+	}
+	assert.True(t, s.Task(nil).EveryNHours(-1).Next.Omit)
+}
+
+func TestScheduler_EveryN_dst(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	ny, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	london, err := time.LoadLocation("Europe/London")
+	assert.NoError(t, err)
+
+	e := s.Task(nil).Timezone(ny).EveryNHours(2)
+	assert.Equal(t, "0 */2 * * *", e.info().Cron)
+	from := time.Date(2022, 7, 5, 0, 30, 0, 0, ny)
+	assert.Equal(t, []string{"07-05 02:00", "07-05 04:00", "07-05 06:00"}, formatRuns(e.Schedule().NextRuns(from, 3)))
+
+	e = s.Task(nil).Timezone(london).EveryNHours(6)
+	assert.Equal(t, "0 */6 * * *", e.info().Cron)
+	from = time.Date(2022, 1, 5, 0, 30, 0, 0, london)
+	assert.Equal(t, []string{"01-05 06:00", "01-05 12:00", "01-05 18:00"}, formatRuns(e.Schedule().NextRuns(from, 3)))
+
+	// the wall clock hours are kept across the daylight saving time change
+	from = time.Date(2022, 3, 26, 20, 0, 0, 0, london)
+	assert.Equal(t, []string{"03-27 00:00", "03-27 06:00", "03-27 12:00"}, formatRuns(e.Schedule().NextRuns(from, 3)))
+
+	e = s.Task(nil).Timezone(ny).EveryNMinutes(20)
+	assert.Equal(t, "*/20 * * * *", e.info().Cron)
+	assert.Equal(t, []string{"07-05 00:40", "07-05 01:00"}, formatRuns(e.Schedule().NextRuns(time.Date(2022, 7, 5, 0, 30, 0, 0, ny), 2)))
+}
 func TestScheduler_Daily(t *testing.T) {
 	s := NewScheduler(context.Background(), time.UTC)
 	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 17:00:00")
@@ -787,3 +1080,233 @@ func TestScheduler_Call(t *testing.T) {
 	s.Start()
 	assert.True(t, mark)
 }
+
+func TestScheduler_tick(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:00")
+	s.SetClock(NewFakeClock(now))
+	ch := make(chan string, 4)
+	s.CallFunc(func(ctx context.Context) {
+		ch <- "none"
+	})
+	s.EveryFiveMinutes().CallFunc(func(ctx context.Context) {
+		ch <- "five"
+	})
+	s.HourlyAt(36).Fridays().CallFunc(func(ctx context.Context) {
+		ch <- "friday"
+	})
+	s.HourlyAt(36).Wednesdays().CallFunc(func(ctx context.Context) {
+		ch <- "wednesday"
+	})
+	s.Start()
+	assert.Len(t, ch, 0)
+	now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:35:00")
+	s.tick(now)
+	s.Start()
+	assert.Len(t, ch, 1)
+	assert.Equal(t, "five", <-ch)
+	now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:36:00")
+	s.tick(now)
+	s.Start()
+	assert.Len(t, ch, 1)
+	assert.Equal(t, "wednesday", <-ch)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan bool, 1)
+	go func() {
+		s.Run(ctx)
+		done <- true
+	}()
+	assert.True(t, <-done)
+}
+
+func TestScheduler_Run_start(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:29:59")
+	clock := NewFakeClock(now)
+	s := New(context.Background(), WithLocation(time.UTC), WithClock(clock))
+	ch := make(chan string, 2)
+	s.Task(func(ctx context.Context) { ch <- "stale" }).Cron("29 * * * *")
+	s.Task(func(ctx context.Context) { ch <- "start" }).Cron("30 * * * *")
+	clock.Advance(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Empty(t, s.Run(ctx))
+	assert.Len(t, ch, 1)
+	assert.Equal(t, "start", <-ch)
+}
+
+func TestScheduler_Name_duplicate(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	logger := &recordLogger{}
+	s.SetStructuredLogger(logger)
+	s.Task(func(ctx context.Context) {}).Name("report").Daily()
+	s.Task(func(ctx context.Context) {}).Name("cleanup").Daily()
+	assert.Nil(t, logger.find("Duplicate task name"))
+
+	s.Task(func(ctx context.Context) {}).Name("report").Hourly()
+	entry := logger.find("Duplicate task name")
+	assert.NotNil(t, entry)
+	assert.Equal(t, LevelError, entry.level)
+	assert.Equal(t, "report", entry.fields[FieldTask])
+
+	// the name set before registration is checked too
+	logger = &recordLogger{}
+	s.SetStructuredLogger(logger)
+	s.Name("cleanup").Daily().CallFunc(func(ctx context.Context) {})
+	assert.NotNil(t, logger.find("Duplicate task name"))
+}
+
+func TestScheduler_Name(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:00")
+	s.Name("billing-rollup").Daily().CallFunc(func(ctx context.Context) {})
+	s.Hourly().CallFunc(func(ctx context.Context) {})
+	tasks := s.Tasks()
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "billing-rollup", tasks[0].Name)
+	assert.Equal(t, "task-2", tasks[1].Name)
+}
+
+func TestScheduler_Tasks(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	prc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	s.now, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:31:00")
+	s.Name("report").Timezone(prc).DailyAt("23:31").Weekdays().Between("09:00", "23:59").When(func(ctx context.Context) bool {
+		return true
+	}).CallFunc(func(ctx context.Context) {})
+	s.Name("panic").EveryMinute().UnlessBetween("01:00", "02:00").CallFunc(func(ctx context.Context) {
+		panic("task panic")
+	})
+	s.Name("idle").Cron("0 0 1 1 *").CallFunc(func(ctx context.Context) {})
+	s.Start()
+	tasks := s.Tasks()
+	assert.Len(t, tasks, 3)
+	assert.Equal(t, "DailyAt(23:31)", tasks[0].Frequency)
+	assert.Equal(t, []string{
+		"Days(Monday, Tuesday, Wednesday, Thursday, Friday)",
+		"Between(09:00, 23:59)",
+		"When()",
+	}, tasks[0].Constraints)
+	assert.Equal(t, "Asia/Shanghai", tasks[0].Timezone)
+	assert.Equal(t, StatusSuccess, tasks[0].Status)
+	assert.False(t, tasks[0].LastRun.IsZero())
+	assert.Nil(t, tasks[0].Panic)
+	assert.Equal(t, "EveryMinute()", tasks[1].Frequency)
+	assert.Equal(t, []string{"UnlessBetween(01:00, 02:00)"}, tasks[1].Constraints)
+	assert.Equal(t, "UTC", tasks[1].Timezone)
+	assert.Equal(t, StatusPanic, tasks[1].Status)
+	assert.Equal(t, "task panic", tasks[1].Panic)
+	assert.Equal(t, "Cron(0 0 1 1 *)", tasks[2].Frequency)
+	assert.Empty(t, tasks[2].Constraints)
+	assert.Equal(t, StatusPending, tasks[2].Status)
+	assert.True(t, tasks[2].LastRun.IsZero())
+}
+
+func TestScheduler_Task(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.current, _ = time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	s.Event = s.newEvent()
+	ch := make(chan string, 3)
+	weekdays := s.Task(func(ctx context.Context) {
+		ch <- "weekdays"
+	}).EveryThirtyMinutes().Weekdays()
+	weekends := s.Task(func(ctx context.Context) {
+		ch <- "weekends"
+	}).EveryThirtyMinutes().Weekends()
+	s.Job(NewDefaultTask(func(ctx context.Context) {
+		ch <- "job"
+	})).Name("job").EveryMinute()
+	assert.Len(t, weekdays.limit.DaysOfWeek, 5)
+	assert.Len(t, weekends.limit.DaysOfWeek, 2)
+	assert.Len(t, s.Tasks(), 3)
+	assert.Len(t, ch, 0)
+	s.Start()
+	assert.Len(t, ch, 2)
+	s.Start()
+	assert.Len(t, ch, 2)
+	assert.ElementsMatch(t, []string{"weekdays", "job"}, []string{<-ch, <-ch})
+}
+
+func TestScheduler_Concurrent(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Task(func(ctx context.Context) {}).Name(strconv.Itoa(i)).Yearly().Weekdays()
+		}(i)
+	}
+	wg.Wait()
+	tasks := s.Tasks()
+	assert.Len(t, tasks, 10)
+	for _, task := range tasks {
+		assert.Equal(t, []string{"Days(Monday, Tuesday, Wednesday, Thursday, Friday)"}, task.Constraints)
+	}
+}
+
+func TestScheduler_SetGracePeriod(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	s.SetGracePeriod(10 * time.Millisecond)
+	release := make(chan bool)
+	s.Task(func(ctx context.Context) {}).Name("quick").EveryMinute()
+	assert.Empty(t, s.Start())
+	s.Task(func(ctx context.Context) {
+		<-release
+	}).Name("hung").EveryMinute()
+	assert.Equal(t, []string{"hung"}, s.Start())
+	assert.Equal(t, StatusRunning, s.Tasks()[1].Status)
+	close(release)
+	assert.Empty(t, s.SetGracePeriod(0).Start())
+	assert.Equal(t, StatusSuccess, s.Tasks()[1].Status)
+}
+
+func TestScheduler_SetGracePeriod_repeat(t *testing.T) {
+	now, _ := time.Parse("2006-01-02 15:04:05", "2022-10-05 15:30:00")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := New(ctx, WithLocation(time.UTC), WithClock(NewFakeClock(now)))
+	s.SetGracePeriod(10 * time.Millisecond)
+	s.Task(func(ctx context.Context) {}).Name("poll").EveryThirtySeconds()
+	results, err := s.Wait()
+	assert.Error(t, err)
+	assert.Contains(t, results, Result{Name: "poll", Status: StatusRunning})
+}
+
+func TestScheduler_RunTask(t *testing.T) {
+	s := NewScheduler(context.Background(), time.UTC)
+	var count int32
+	s.Task(func(ctx context.Context) {
+		atomic.AddInt32(&count, 1)
+	}).Name("report").Yearly().Between("00:00", "00:01")
+	s.TaskE(func(ctx context.Context) error {
+		return errors.New("broken")
+	}).Name("broken").Yearly()
+	s.Task(func(ctx context.Context) {}).Name("locked").Yearly().WithoutOverlapping(time.Minute)
+
+	r, err := s.RunTask("report")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSuccess, r.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	r, err = s.RunTask("broken")
+	var runErr *RunError
+	assert.ErrorAs(t, err, &runErr)
+	assert.Equal(t, StatusFailed, r.Status)
+
+	r, err = s.RunTask("missing")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+	assert.Equal(t, "missing", r.Name)
+
+	ok, err := s.mutex.Lock("locked", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	r, err = s.RunTask("locked")
+	assert.ErrorIs(t, err, ErrTaskSkipped)
+	assert.Equal(t, StatusPending, r.Status)
+}
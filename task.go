@@ -5,6 +5,8 @@ package schedule
 import (
 	"context"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,7 +15,13 @@ type Task interface {
 	Run(ctx context.Context)
 }
 
-// Logger logger interface for scheduler logger
+// TaskE the task interface which can report its failure, the failing task can be retried by `Retry`
+type TaskE interface {
+	Run(ctx context.Context) error
+}
+
+// Logger the legacy logger interface for scheduler logger, it's wrapped to log the fields in message.
+// Implement StructuredLogger for the leveled logs with key/value fields.
 type Logger interface {
 	Error(msg string, e any)
 	Debugf(msg string, n int32)
@@ -23,6 +31,9 @@ type Logger interface {
 // TaskFunc the function of task
 type TaskFunc func(ctx context.Context)
 
+// TaskFuncE the function of task which can report its failure
+type TaskFuncE func(ctx context.Context) error
+
 // WhenFunc the function define of task constraint
 type WhenFunc func(ctx context.Context) bool
 
@@ -38,6 +49,27 @@ func (d *DefaultTask) Run(ctx context.Context) {
 	d.fn(ctx)
 }
 
+type DefaultTaskE struct {
+	fn TaskFuncE
+}
+
+func NewDefaultTaskE(fn TaskFuncE) *DefaultTaskE {
+	return &DefaultTaskE{fn: fn}
+}
+
+func (d *DefaultTaskE) Run(ctx context.Context) error {
+	return d.fn(ctx)
+}
+
+// errorTask adapt TaskE to Task, so the event can hold both of them
+type errorTask struct {
+	task TaskE
+}
+
+func (t *errorTask) Run(ctx context.Context) {
+	_ = t.task.Run(ctx)
+}
+
 type NextTick struct {
 	Year   int
 	Month  int
@@ -47,15 +79,195 @@ type NextTick struct {
 	Omit   bool
 }
 
+func (n *NextTick) match(now time.Time) bool {
+	if n.Omit {
+		return false
+	}
+	if n.Year == now.Year() &&
+		n.Month == int(now.Month()) &&
+		n.Day == now.Day() &&
+		n.Hour == now.Hour() &&
+		n.Minute == now.Minute() {
+		return true
+	}
+	return false
+}
+
+// onDay report whether the tick is on the day of now, the frequency never fires on the day if it's not
+func (n *NextTick) onDay(now time.Time) bool {
+	return n.Year == now.Year() && n.Month == int(now.Month()) && n.Day == now.Day()
+}
+
+func (n *NextTick) setTime(now time.Time, t []string) {
+	currentHour := now.Hour()
+	currentMinute := now.Minute()
+	var hour, minute int
+	var err error
+	for _, v := range t {
+		hm := strings.Split(v, ":")
+		if len(hm) == 2 {
+			hour, err = strconv.Atoi(hm[0])
+			if err == nil {
+				minute, err = strconv.Atoi(hm[1])
+				if err == nil {
+					if currentHour == hour && currentMinute == minute {
+						n.Hour = currentHour
+						n.Minute = currentMinute
+						n.Omit = false
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// frequency the function compute the tick of a frequency base on current time,
+// the date of tick only depends on the date of now, so the days never fire can be skipped.
+type frequency func(now time.Time) *NextTick
+
 type Limit struct {
 	DaysOfWeek []time.Weekday
 	StartTime  string
 	EndTime    string
 	IsBetween  bool
 	When       WhenFunc
+	// shift the delay of spread frequency, the days and times are checked at the time before it
+	shift time.Duration
+}
+
+func (l *Limit) check(ctx context.Context, now time.Time) bool {
+	if !l.allow(now) {
+		return false
+	}
+	if l.When != nil {
+		return l.When(ctx)
+	}
+	return true
+}
+
+func (l *Limit) allow(now time.Time) bool {
+	if !l.allowDay(now) {
+		return false
+	}
+	now = now.Add(-l.shift)
+	startMinute, endMinute := l.window()
+	minuteOffset := now.Hour()*60 + now.Minute()
+	if l.IsBetween && (minuteOffset < startMinute || minuteOffset > endMinute) {
+		return false
+	} else if !l.IsBetween && minuteOffset > startMinute && minuteOffset < endMinute {
+		return false
+	}
+	return true
+}
+
+// window return the start and end minutes of day of the time constraint
+func (l *Limit) window() (startMinute, endMinute int) {
+	var hour, minute int
+	if l.StartTime != "" {
+		hour, minute = timeToMinutes(l.StartTime)
+		startMinute = hour*60 + minute
+	}
+	if l.EndTime != "" {
+		hour, minute = timeToMinutes(l.EndTime)
+		endMinute = hour*60 + minute
+	}
+	if startMinute > endMinute {
+		temp := startMinute
+		startMinute = endMinute
+		endMinute = temp
+	}
+	return startMinute, endMinute
+}
+
+// nextTime return the next time may be allowed by the time constraint after now, it's the next minute at least
+func (l *Limit) nextTime(now time.Time) time.Time {
+	next := now.Add(time.Minute)
+	if l.StartTime == "" && l.EndTime == "" {
+		return next
+	}
+	startMinute, endMinute := l.window()
+	d := now.Add(-l.shift)
+	minuteOffset := d.Hour()*60 + d.Minute()
+	var at int
+	switch {
+	case l.IsBetween && minuteOffset < startMinute:
+		at = startMinute
+	case l.IsBetween && minuteOffset > endMinute:
+		return nextDay(now, l.shift)
+	case !l.IsBetween && minuteOffset > startMinute && minuteOffset < endMinute:
+		at = endMinute
+	default:
+		return next
+	}
+	return wallClock(now, time.Date(d.Year(), d.Month(), d.Day(), at/60, at%60, 0, 0, d.Location()), l.shift)
+}
+
+// allowDay check the day of week only
+func (l *Limit) allowDay(now time.Time) bool {
+	if len(l.DaysOfWeek) == 0 {
+		return true
+	}
+	weekday := now.Add(-l.shift).Weekday()
+	for _, day := range l.DaysOfWeek {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Limit) describe() []string {
+	var constraints []string
+	if len(l.DaysOfWeek) > 0 {
+		days := make([]string, 0, len(l.DaysOfWeek))
+		for _, d := range l.DaysOfWeek {
+			days = append(days, d.String())
+		}
+		constraints = append(constraints, "Days("+strings.Join(days, ", ")+")")
+	}
+	if l.StartTime != "" || l.EndTime != "" {
+		method := "UnlessBetween"
+		if l.IsBetween {
+			method = "Between"
+		}
+		constraints = append(constraints, method+"("+l.StartTime+", "+l.EndTime+")")
+	}
+	if l.When != nil {
+		constraints = append(constraints, "When()")
+	}
+	return constraints
+}
+
+// Status the outcome of the last run of a task
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusSuccess Status = "success"
+	StatusFailed  Status = "failed"
+	StatusPanic   Status = "panic"
+	StatusTimeout Status = "timeout"
+)
+
+// TaskInfo the information of a registered task
+type TaskInfo struct {
+	Name        string
+	Frequency   string
+	Cron        string
+	Constraints []string
+	Timezone    string
+	Status      Status
+	LastRun     time.Time
+	Duration    time.Duration
+	Err         error
+	Panic       any
 }
 
 type DefaultLogger struct {
+	// Level the minimum level of logs printed by Log, the zero value is LevelInfo
+	Level Level
 }
 
 func (d *DefaultLogger) Error(msg string, r any) {